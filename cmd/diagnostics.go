@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/fdrolshagen/jetter/internal"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// PrintDiagnostics prints the diagnostics along with an excerpt of the line they refer to.
+func PrintDiagnostics(diagnostics []internal.Diagnostic) {
+	sources := map[string][]string{}
+	for _, d := range diagnostics {
+		icon, label := "❌", color.RedString("error")
+		if d.Severity == internal.SeverityWarning {
+			icon, label = color.YellowString(warningIcon), color.YellowString("warning")
+		}
+		fmt.Printf("%s %s[%s]: %s\n", icon, label, d.Code, d.Message)
+
+		switch {
+		case d.Line == 0:
+			fmt.Printf("  --> %s\n", d.File)
+		case d.File == "":
+			fmt.Printf("  --> line %d, column %d\n", d.Line, d.Column)
+		default:
+			fmt.Printf("  --> %s:%d:%d\n", d.File, d.Line, d.Column)
+		}
+
+		gutter := strings.Repeat(" ", len(strconv.Itoa(d.Line)))
+		if line, ok := sourceLine(sources, d.File, d.Line); ok {
+			fmt.Printf(" %s |\n", gutter)
+			fmt.Printf(" %d | %s\n", d.Line, line)
+			fmt.Printf(" %s | %s%s\n", gutter, caretIndent(line, d.Column), color.RedString("^"))
+		}
+		if d.Fix != "" {
+			fmt.Printf(" %s = fix: %s\n", gutter, d.Fix)
+		}
+		fmt.Println()
+	}
+}
+
+// sourceLine returns the given line of the file, reading each file only once.
+func sourceLine(sources map[string][]string, file string, line int) (string, bool) {
+	if file == "" {
+		return "", false
+	}
+	lines, ok := sources[file]
+	if !ok {
+		content, err := os.ReadFile(file)
+		if err == nil {
+			lines = strings.Split(string(content), "\n")
+		}
+		sources[file] = lines
+	}
+	if line < 1 || line > len(lines) {
+		return "", false
+	}
+	return strings.TrimRight(lines[line-1], "\r"), true
+}
+
+// caretIndent returns the whitespace in front of the caret pointing at column,
+// keeping tabs so that the caret lines up with the excerpt.
+func caretIndent(line string, column int) string {
+	var b strings.Builder
+	for i := 0; i < column-1; i++ {
+		if i < len(line) && line[i] == '\t' {
+			b.WriteByte('\t')
+		} else {
+			b.WriteByte(' ')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/fdrolshagen/jetter/internal/diff"
+	"github.com/fdrolshagen/jetter/internal/parser"
+	"github.com/spf13/cobra"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+func newFmtCmd(exitCode *int) *cobra.Command {
+	var check, showDiff bool
+	cmd := &cobra.Command{
+		Use:   "fmt <file or directory>...",
+		Short: "Format .http files in the canonical style",
+		Long: "Fmt rewrites .http files in the canonical style. Directories are searched for .http files recursively.\n" +
+			"With --check or --diff, files are not modified.",
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			files, err := httpFiles(args)
+			if err != nil {
+				return err
+			}
+
+			unformatted := 0
+			for _, file := range files {
+				changed, err := formatFile(file, check || showDiff, showDiff)
+				if err != nil {
+					return err
+				}
+				if changed {
+					unformatted++
+				}
+			}
+
+			if check && unformatted > 0 {
+				*exitCode = 1
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&check, "check", false, "List files that are not formatted and exit non-zero if there are any")
+	cmd.Flags().BoolVar(&showDiff, "diff", false, "Print the changes formatting would make")
+	return cmd
+}
+
+// formatFile formats the file and reports whether its content changed. Unless dryRun
+// is set, the file is rewritten. The name of a changed file is printed, or its diff.
+func formatFile(file string, dryRun, showDiff bool) (bool, error) {
+	src, err := os.ReadFile(file)
+	if err != nil {
+		return false, err
+	}
+	formatted := parser.Format(src)
+	if bytes.Equal(src, formatted) {
+		return false, nil
+	}
+
+	if showDiff {
+		fmt.Print(diff.Unified(file, file+" (formatted)", src, formatted))
+	} else {
+		fmt.Println(file)
+	}
+	if dryRun {
+		return true, nil
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return true, err
+	}
+	return true, os.WriteFile(file, formatted, info.Mode().Perm())
+}
+
+// httpFiles returns the given files and the .http files within the given directories.
+func httpFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && filepath.Ext(p) == ".http" {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
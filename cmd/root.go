@@ -1,29 +1,40 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"github.com/fatih/color"
 	"github.com/fdrolshagen/jetter/internal"
 	"github.com/fdrolshagen/jetter/internal/executor"
 	"github.com/fdrolshagen/jetter/internal/inject"
 	"github.com/fdrolshagen/jetter/internal/parser"
+	"github.com/fdrolshagen/jetter/internal/random"
 	"github.com/fdrolshagen/jetter/internal/reporter"
+	"github.com/fdrolshagen/jetter/internal/validate"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"os"
 	"time"
 )
 
 var (
-	duration    time.Duration
-	concurrency int
-	file        string
-	envPath     string
-	showVersion bool
+	duration        time.Duration
+	concurrency     int
+	thinkTime       time.Duration
+	file            string
+	envPath         string
+	outputPolicy    string
+	showVersion     bool
+	strict          bool
+	baseURL         string
+	allowUnresolved bool
+	seed            uint64
 )
 
 const (
 	pendingIcon = "⏳"
 	successIcon = "✔"
+	warningIcon = "⚠"
 )
 
 func Execute() {
@@ -34,7 +45,7 @@ func Execute() {
 		Long:  "Jetter runs load tests based on .http scenario files.",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			PrintBanner()
-			exitCode = run()
+			exitCode = run(cmd.Flags())
 			return nil
 		},
 	}
@@ -43,9 +54,18 @@ func Execute() {
 	rootCmd.Flags().DurationVarP(&duration, "duration", "d", 0,
 		"How long should the load test run (accepts duration format, e.g. 30s, 1m)")
 	rootCmd.Flags().IntVarP(&concurrency, "concurrency", "c", 1, "Number of concurrent workers")
+	rootCmd.Flags().DurationVar(&thinkTime, "think-time", 0, "Pause after each request (accepts duration format, e.g. 200ms)")
 	rootCmd.Flags().StringVarP(&file, "file", "f", "", "Path to the .http file")
 	rootCmd.Flags().StringVarP(&envPath, "env", "e", "", "Path to the environment file")
+	rootCmd.Flags().StringVar(&outputPolicy, "output-policy", string(internal.OutputFirst),
+		"Which responses are written to '>>' output files (first, failures, all)")
+	rootCmd.Flags().BoolVar(&strict, "strict", false, "Warn about requests with non-standard HTTP methods")
+	rootCmd.Flags().BoolVar(&allowUnresolved, "allow-unresolved", false, "Send requests with unresolved {{variables}} as is")
+	rootCmd.Flags().Uint64Var(&seed, "seed", 0, "Seed of the random values of dynamic variables, to reproduce a run (default: random)")
+	rootCmd.Flags().StringVar(&baseURL, "base-url", "", "Base URL of relative request URLs, e.g. https://staging.example.com")
 	rootCmd.MarkFlagRequired("file")
+	rootCmd.AddCommand(newValidateCmd(&exitCode))
+	rootCmd.AddCommand(newFmtCmd(&exitCode))
 
 	rootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
 		if showVersion {
@@ -61,15 +81,28 @@ func Execute() {
 	os.Exit(exitCode)
 }
 
-func run() int {
+func run(flags *pflag.FlagSet) int {
+	policy, err := internal.ParseOutputPolicy(outputPolicy)
+	if err != nil {
+		PrintError(err)
+		os.Exit(1)
+	}
+
 	msg := "Parsing .http file..."
 	fmt.Printf("%s %s", pendingIcon, msg)
-	collection, err := parser.ParseHttpFile(file)
+	collection, err := parser.ParseHttpFileWithOptions(file, parser.Options{Strict: strict})
+	var diagnostics internal.Diagnostics
+	if errors.As(err, &diagnostics) {
+		fmt.Printf("\n\n")
+		PrintDiagnostics(diagnostics)
+		os.Exit(1)
+	}
 	if err != nil {
 		PrintError(err)
 		os.Exit(1)
 	}
 	fmt.Printf("\r%s %s\n", color.GreenString(successIcon), msg)
+	PrintDiagnostics(collection.Warnings)
 
 	if envPath != "" {
 		err = handleEnvInjection(envPath, &collection)
@@ -79,12 +112,20 @@ func run() int {
 		}
 	}
 
-	s := internal.Scenario{
-		Concurrency: concurrency,
-		Collection:  &collection,
-		Duration:    duration,
+	requests, err := executor.Evaluate(&collection)
+	if err != nil {
+		PrintError(err)
+		os.Exit(1)
+	}
+	if unresolved := validate.Unresolved(collection, requests); len(unresolved) > 0 && !allowUnresolved {
+		fmt.Println()
+		PrintDiagnostics(unresolved)
+		os.Exit(1)
 	}
 
+	s := newScenario(flags, &collection)
+	s.OutputPolicy = policy
+
 	msg = "Running Scenario..."
 	fmt.Printf("%s %s", pendingIcon, msg)
 	result := executor.Submit(s)
@@ -94,6 +135,43 @@ func run() int {
 	return map[bool]int{true: 1, false: 0}[result.AnyError]
 }
 
+// newScenario merges the load profile of the command line flags and the `#@jetter`
+// directives of the collection. Flags given on the command line take precedence over
+// directives, which take precedence over the flag defaults. Without a seed, a random seed
+// is chosen, which is printed in the report.
+func newScenario(flags *pflag.FlagSet, collection *internal.Collection) internal.Scenario {
+	s := internal.Scenario{
+		Collection:  collection,
+		Concurrency: concurrency,
+		Duration:    duration,
+		ThinkTime:   thinkTime,
+		BaseURL:     baseURL,
+	}
+
+	config := collection.Config
+	if !flags.Changed("concurrency") && config.Concurrency > 0 {
+		s.Concurrency = config.Concurrency
+	}
+	if !flags.Changed("duration") && config.Duration > 0 {
+		s.Duration = config.Duration
+	}
+	if !flags.Changed("think-time") && config.ThinkTime > 0 {
+		s.ThinkTime = config.ThinkTime
+	}
+	if !flags.Changed("base-url") {
+		s.BaseURL = config.BaseURL
+	}
+	switch {
+	case flags.Changed("seed"):
+		s.Seed = seed
+	case config.Seed != nil:
+		s.Seed = *config.Seed
+	default:
+		s.Seed = random.NewSeed()
+	}
+	return s
+}
+
 func PrintError(err error) {
 	if err != nil {
 		fmt.Printf("\n\n❌ Error: %s\n", err.Error())
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/fdrolshagen/jetter/internal"
+	"github.com/fdrolshagen/jetter/internal/parser"
+	"github.com/fdrolshagen/jetter/internal/validate"
+	"github.com/spf13/cobra"
+	"os"
+	"strings"
+)
+
+// validationReport is the JSON output of the validate command.
+type validationReport struct {
+	File        string                `json:"file"`
+	Valid       bool                  `json:"valid"`
+	Errors      int                   `json:"errors"`
+	Warnings    int                   `json:"warnings"`
+	Diagnostics []internal.Diagnostic `json:"diagnostics"`
+}
+
+func newValidateCmd(exitCode *int) *cobra.Command {
+	var file, envPath, format, baseURL string
+	var strict bool
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Check a .http scenario file without running it",
+		Long: "Validate parses the .http file and reports unresolved variables, unknown dynamic variables, " +
+			"missing auth configurations, duplicate request names and invalid URLs.\n" +
+			"With --strict, requests with non-standard HTTP methods are reported as well.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != "human" && format != "json" {
+				return fmt.Errorf("invalid format '%s', must be one of human, json", format)
+			}
+			diagnostics := validateFile(file, envPath, baseURL, strict)
+			report := newValidationReport(file, diagnostics)
+			if format == "json" {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(report); err != nil {
+					return err
+				}
+			} else {
+				printValidationReport(report)
+			}
+			*exitCode = map[bool]int{true: 0, false: 1}[report.Valid]
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&file, "file", "f", "", "Path to the .http file")
+	cmd.Flags().StringVarP(&envPath, "env", "e", "", "Path to the environment file")
+	cmd.Flags().StringVar(&format, "format", "human", "Output format (human, json)")
+	cmd.Flags().BoolVar(&strict, "strict", false, "Warn about requests with non-standard HTTP methods")
+	cmd.Flags().StringVar(&baseURL, "base-url", "", "Base URL of relative request URLs")
+	cmd.MarkFlagRequired("file")
+	return cmd
+}
+
+// validateFile parses and validates the .http file. Problems reading the file or the
+// environment are reported as diagnostics as well. A base URL given on the command line
+// replaces the one of the file.
+func validateFile(file, envPath, baseURL string, strict bool) internal.Diagnostics {
+	collection, err := parser.ParseHttpFileWithOptions(file, parser.Options{Strict: strict})
+	var diagnostics internal.Diagnostics
+	if errors.As(err, &diagnostics) {
+		return diagnostics
+	}
+	if err != nil {
+		return internal.Diagnostics{fileDiagnostic(file, "invalid-file", err)}
+	}
+	diagnostics = collection.Warnings
+	if baseURL != "" {
+		collection.Config.BaseURL = baseURL
+	}
+
+	var env *internal.Environment
+	if envPath != "" {
+		e, err := parser.ParseEnv(envPath)
+		if err != nil {
+			envFile, _, _ := strings.Cut(envPath, ":")
+			return append(diagnostics, fileDiagnostic(envFile, "invalid-environment", err))
+		}
+		env = &e
+	}
+
+	return append(diagnostics, validate.Validate(collection, env)...)
+}
+
+func fileDiagnostic(file, code string, err error) internal.Diagnostic {
+	return internal.Diagnostic{
+		Severity: internal.SeverityError,
+		File:     file,
+		Code:     code,
+		Message:  err.Error(),
+	}
+}
+
+func newValidationReport(file string, diagnostics internal.Diagnostics) validationReport {
+	report := validationReport{File: file, Diagnostics: diagnostics}
+	if report.Diagnostics == nil {
+		report.Diagnostics = []internal.Diagnostic{}
+	}
+	for _, d := range diagnostics {
+		if d.Severity == internal.SeverityWarning {
+			report.Warnings++
+		} else {
+			report.Errors++
+		}
+	}
+	report.Valid = report.Errors == 0
+	return report
+}
+
+func printValidationReport(report validationReport) {
+	PrintDiagnostics(report.Diagnostics)
+	if report.Valid {
+		fmt.Printf("%s %s is valid (%d warnings)\n", color.GreenString(successIcon), report.File, report.Warnings)
+		return
+	}
+	fmt.Printf("❌ %s is invalid (%d errors, %d warnings)\n", report.File, report.Errors, report.Warnings)
+}
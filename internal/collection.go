@@ -3,71 +3,377 @@ package internal
 import (
 	"fmt"
 	"github.com/fdrolshagen/jetter/internal/random"
+	"maps"
 	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Request represents a single HTTP request definition within a jetter scenario.
 // It defines all necessary details for execution, including the method, target URL,
-// optional headers, and request body content.
+// optional headers, request body content, and an optional response handler script.
+// The body is either given inline, read from a file referenced by BodyFile,
+// or built from the parts of a Multipart body. GraphQL requests carry their
+// operation in GraphQL, WebSocket requests their messages in WebSocket instead of Body.
+// gRPC requests are marked by GRPC, their Url is parsed by ParseGRPCTarget.
+// SSE is set by `#@jetter sse` to read the response as a stream of server-sent events.
+// HttpVersion is empty unless a protocol version is given on the request line.
+// File and Line locate the request line, File is empty if the collection was not read from a file.
+// HeaderLines and BodyLine locate the headers and the first line of the body in File.
+// Variables holds overrides given by a `run` statement, which take precedence
+// over all other variables for this request.
 type Request struct {
-	Name    string
+	Name            string
+	File            string
+	Line            int
+	Method          string
+	Url             string
+	HttpVersion     string
+	Headers         map[string]string
+	HeaderLines     map[string]int
+	BodyLine        int
+	Variables       map[string]string
+	Body            string
+	BodyFile        *BodyFile
+	Multipart       *MultipartBody
+	GraphQL         *GraphQL
+	WebSocket       *WebSocket
+	GRPC            *GRPC
+	SSE             *SSE
+	ResponseHandler string
+	ResponseOutput  *ResponseOutput
+	Options         RequestOptions
+	// Weight is the number of times the request is executed within each execution.
+	// Zero means once.
+	Weight int
+	// ThinkTime overrides the scenario's pause after the request if set.
+	ThinkTime time.Duration
+}
+
+// RequestOptions holds the per-request settings given by IntelliJ directives
+// such as `# @timeout 10` or `# @no-redirect` in front of the request line.
+type RequestOptions struct {
+	// Timeout overrides the default request timeout if set.
+	Timeout time.Duration
+	// NoRedirect disables following redirects.
+	NoRedirect bool
+	// NoCookieJar disables sending and storing cookies of the execution's cookie jar.
+	NoCookieJar bool
+	// NoLog disables writing the response to its ResponseOutput.
+	NoLog bool
+}
+
+// Protocol versions that can be given on the request line.
+const (
+	HTTP11 = "HTTP/1.1"
+	HTTP2  = "HTTP/2"
+)
+
+// BodyFile references a file whose content is streamed as request body on every execution.
+// Unless Raw is set, variables within the file content are substituted the same way
+// as within inline bodies.
+type BodyFile struct {
+	Path string
+	Raw  bool
+}
+
+// GraphQL is the operation of a `GRAPHQL` request, which is sent as a GraphQL-over-HTTP
+// POST with a JSON body. Variables holds the JSON object given after the query, or is
+// empty. All fields may contain variables, which are substituted before sending.
+type GraphQL struct {
+	Query         string
+	OperationName string
+	Variables     string
+}
+
+// WebSocket holds the steps of a `WEBSOCKET` request, which are executed in order on a
+// single connection once the handshake is done. The connection is closed after the last step.
+type WebSocket struct {
+	Steps []WebSocketStep
+}
+
+// WebSocketStep sends Message to the server or, if WaitForServer is set, waits for the
+// next message of the server. Messages may contain variables.
+type WebSocketStep struct {
+	Message       string
+	WaitForServer bool
+}
+
+// SSE holds the limits of a server-sent events stream. The stream is closed after Duration
+// or once Events events are received, whichever comes first. Without limits, it is read
+// until the server closes it.
+type SSE struct {
+	Duration time.Duration
+	Events   int
+}
+
+// GRPC marks a `GRPC host:port/package.Service/Method` request. Its body holds the request
+// messages as JSON objects: one for unary and server streaming methods, any number for client
+// and bidirectional streaming methods. The message types are resolved from Protos, which are
+// .proto files or binary descriptor sets, or by server reflection if there are none.
+type GRPC struct {
+	Protos []string
+}
+
+// GRPCTarget is the parsed URL of a gRPC request.
+type GRPCTarget struct {
+	Address string
+	// Service is the fully-qualified service name, e.g. `package.Service`.
+	Service string
 	Method  string
-	Url     string
-	Headers map[string]string
-	Body    string
+	TLS     bool
+}
+
+// FullMethod returns the method name as used on the wire, e.g. `/package.Service/Method`.
+func (t GRPCTarget) FullMethod() string {
+	return "/" + t.Service + "/" + t.Method
+}
+
+// ParseGRPCTarget parses the URL of a gRPC request: the address of the server, optionally
+// prefixed by `grpc://` or `http://`, or by `grpcs://` or `https://` for TLS, followed by the
+// fully-qualified service name and the method name.
+func ParseGRPCTarget(url string) (GRPCTarget, error) {
+	var t GRPCTarget
+	rest := url
+	if scheme, after, ok := strings.Cut(url, "://"); ok {
+		switch scheme {
+		case "grpc", "http":
+		case "grpcs", "https":
+			t.TLS = true
+		default:
+			return t, fmt.Errorf("unsupported scheme '%s', use grpc, grpcs, http or https", scheme)
+		}
+		rest = after
+	}
+
+	parts := strings.Split(rest, "/")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return t, fmt.Errorf("expected '<host>:<port>/<package.Service>/<Method>'")
+	}
+	t.Address, t.Service, t.Method = parts[0], parts[1], parts[2]
+	return t, nil
+}
+
+// BaseURLVariable is the variable that gives the base URL of relative request URLs unless
+// the scenario has one, e.g. from the environment file.
+const BaseURLVariable = "baseUrl"
+
+// IsRelativeURL reports whether url is an origin-form request target like `/users`,
+// which is resolved against the base URL.
+func IsRelativeURL(url string) bool {
+	return strings.HasPrefix(url, "/")
+}
+
+// ResolveURL appends the relative url to base, keeping the path of base.
+func ResolveURL(base, url string) string {
+	return strings.TrimSuffix(base, "/") + url
 }
 
 // Collection represents a reusable group of HTTP requests that make up
 // a scenario to be executed by jetter. It may also include variable definitions
-// that can be referenced within individual requests.
+// that can be referenced within individual requests, the load profile given in the
+// .http file, and warnings about parts of the .http file that jetter ignores.
+// VariablePositions holds where each of the variables is defined. Variables declared
+// between requests are kept in ScopedVariables, in the order of their declaration.
 type Collection struct {
-	Requests  []Request
-	Variables map[string]string
+	Requests          []Request
+	Variables         map[string]string
+	VariablePositions map[string]Position
+	ScopedVariables   []ScopedVariable
+	Config            ScenarioConfig
+	Warnings          []Diagnostic
+}
+
+// ScopedVariable is an in-place variable declared after the first request. It applies to
+// the requests from index Request on, shadowing Variables and earlier declarations.
+type ScopedVariable struct {
+	Name     string
+	Value    string
+	Request  int
+	Position Position
+}
+
+// ScopeAt returns the scoped variables that apply to the request at index.
+func ScopeAt(scoped []ScopedVariable, index int) map[string]string {
+	vars := make(map[string]string)
+	for _, v := range scoped {
+		if v.Request <= index {
+			vars[v.Name] = v.Value
+		}
+	}
+	return vars
 }
 
-var funcRegex = regexp.MustCompile(`\{\{\s*\$([a-zA-Z0-9_]+)\.([a-zA-Z0-9_]+)\((.*?)\)\s*}}`)
+// Position is the location of a definition within a .http file.
+type Position struct {
+	File string
+	Line int
+}
+
+// MultipartBody is a multipart/form-data request body, built from its parts
+// on every execution using the boundary of the request's Content-Type header.
+type MultipartBody struct {
+	Boundary string
+	Parts    []MultipartPart
+}
 
+// MultipartPart is a single part of a MultipartBody. Its content is either
+// given inline or read from a file referenced by File.
+type MultipartPart struct {
+	Headers map[string]string
+	Content string
+	File    *BodyFile
+}
+
+// ResponseOutput references a file the response body is written to.
+// Unless Overwrite is set, an existing file is kept and a numeric suffix
+// is added to the file name instead.
+type ResponseOutput struct {
+	Path      string
+	Overwrite bool
+}
+
+// funcRegex matches dynamic variables, either bare like `{{$uuid}}` or namespaced like
+// `{{$random.integer(1, 10)}}`. Parentheses are optional for functions without arguments.
+var funcRegex = regexp.MustCompile(`\{\{\s*\$([a-zA-Z0-9_]+)(?:\.([a-zA-Z0-9_]+))?(?:\((.*?)\))?\s*}}`)
+
+// FunctionCall is a `{{$namespace.name(arg)}}` placeholder found at Offset within a string.
+// Namespace is empty for bare dynamic variables like `{{$uuid}}`.
+type FunctionCall struct {
+	Namespace string
+	Name      string
+	Arg       string
+	Offset    int
+}
+
+// FindFunctionCalls returns the function call placeholders within input.
+func FindFunctionCalls(input string) []FunctionCall {
+	var calls []FunctionCall
+	for _, m := range funcRegex.FindAllStringSubmatchIndex(input, -1) {
+		calls = append(calls, newFunctionCall(input, m))
+	}
+	return calls
+}
+
+// newFunctionCall returns the function call of a funcRegex submatch index within input.
+func newFunctionCall(input string, m []int) FunctionCall {
+	call := FunctionCall{Name: input[m[2]:m[3]], Offset: m[0]}
+	if m[4] >= 0 {
+		call.Namespace, call.Name = call.Name, input[m[4]:m[5]]
+	}
+	if m[6] >= 0 {
+		call.Arg = input[m[6]:m[7]]
+	}
+	return call
+}
+
+// CallFunction evaluates a function call using the given random source, which may be nil.
+func CallFunction(call FunctionCall, src *random.Source) (string, error) {
+	switch call.Namespace {
+	case "":
+		return callBareFunction(call, src)
+	case "random":
+		return src.Execute(call.Name, call.Arg)
+	case "auth":
+		return "", fmt.Errorf("'$auth.%s' is only available with an environment that defines Security.Auth", call.Name)
+	default:
+		return "", fmt.Errorf("unsupported namespace '%s'", call.Namespace)
+	}
+}
+
+// callBareFunction evaluates the dynamic variables without namespace, which take no arguments.
+func callBareFunction(call FunctionCall, src *random.Source) (string, error) {
+	if strings.TrimSpace(call.Arg) != "" {
+		return "", fmt.Errorf("dynamic variable '$%s' takes no arguments", call.Name)
+	}
+	switch call.Name {
+	case "uuid":
+		return src.Execute("uuid", "")
+	case "randomInt":
+		return src.Execute("integer", "")
+	case "timestamp":
+		return strconv.FormatInt(time.Now().Unix(), 10), nil
+	case "isoTimestamp":
+		return time.Now().UTC().Format("2006-01-02T15:04:05.000Z"), nil
+	default:
+		return "", fmt.Errorf("unknown dynamic variable '$%s'", call.Name)
+	}
+}
+
+// EvaluateVariables returns the collection variables with their functions evaluated and
+// their references to other variables resolved. Variables referencing each other return
+// a *CycleError.
 func (c *Collection) EvaluateVariables() (map[string]string, error) {
-	resolved := make(map[string]string, len(c.Variables))
-	for k, v := range c.Variables {
-		r, err := replaceFunctions(v, k)
+	return c.EvaluateVariablesWith(nil)
+}
+
+// EvaluateVariablesWith evaluates the variables like EvaluateVariables, using the given
+// random source for their functions. The variables are evaluated in order of their names,
+// so that a seeded source yields the same values on every run.
+func (c *Collection) EvaluateVariablesWith(src *random.Source) (map[string]string, error) {
+	evaluated := make(map[string]string, len(c.Variables))
+	for _, k := range slices.Sorted(maps.Keys(c.Variables)) {
+		r, err := replaceFunctions(c.Variables[k], k, src)
 		if err != nil {
 			return nil, fmt.Errorf("error in variable '%s': %w", k, err)
 		}
-		resolved[k] = r
+		evaluated[k] = r
+	}
+	return ResolveVariables(evaluated)
+}
+
+// EvaluateScopedVariables returns the scoped variables with their functions evaluated.
+// References are resolved in declaration order against vars, the evaluated collection
+// variables, and the scoped variables declared before, so that a scoped variable may
+// refer to the value it shadows. Functions use the given random source, which may be nil.
+func (c *Collection) EvaluateScopedVariables(vars map[string]string, src *random.Source) ([]ScopedVariable, error) {
+	current := maps.Clone(vars)
+	if current == nil {
+		current = make(map[string]string)
+	}
+	resolved := make([]ScopedVariable, len(c.ScopedVariables))
+	for i, v := range c.ScopedVariables {
+		r, err := replaceFunctions(v.Value, v.Name, src)
+		if err != nil {
+			return nil, fmt.Errorf("error in variable '%s': %w", v.Name, err)
+		}
+		resolved[i] = v
+		resolved[i].Value = SubstituteReferences(r, current)
+		current[v.Name] = resolved[i].Value
 	}
 	return resolved, nil
 }
 
-func replaceFunctions(input, varName string) (string, error) {
+func replaceFunctions(input, varName string, src *random.Source) (string, error) {
+	result, err := EvaluateFunctions(input, src)
+	if err != nil {
+		return "", fmt.Errorf("error in variable '%s': %v", varName, err)
+	}
+	return result, nil
+}
+
+// EvaluateFunctions replaces the dynamic variables within input. Every occurrence is
+// evaluated on its own, so `{{$uuid}}/{{$uuid}}` yields two different UUIDs. Random values
+// are taken from src, which may be nil.
+func EvaluateFunctions(input string, src *random.Source) (string, error) {
+	if !strings.Contains(input, "$") {
+		return input, nil
+	}
+
 	result := ""
 	lastIndex := 0
 
 	matches := funcRegex.FindAllStringSubmatchIndex(input, -1)
 	for _, match := range matches {
 		start, end := match[0], match[1]
-		nsStart, nsEnd := match[2], match[3]
-		funcStart, funcEnd := match[4], match[5]
-		argStart, argEnd := match[6], match[7]
 
 		result += input[lastIndex:start]
 
-		namespace := input[nsStart:nsEnd]
-		funcName := input[funcStart:funcEnd]
-		arg := input[argStart:argEnd]
-
-		var out string
-		var err error
-
-		switch namespace {
-		case "random":
-			out, err = random.Execute(funcName, arg)
-		default:
-			return "", fmt.Errorf("error in variable '%s': unsupported namespace '%s'", varName, namespace)
-		}
-
+		out, err := CallFunction(newFunctionCall(input, match), src)
 		if err != nil {
-			return "", fmt.Errorf("error in variable '%s': %v", varName, err)
+			return "", err
 		}
 
 		result += out
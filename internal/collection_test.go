@@ -1,6 +1,7 @@
 package internal
 
 import (
+	"github.com/fdrolshagen/jetter/internal/random"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -64,7 +65,35 @@ func TestEvaluateVariables_BasicReplacement(t *testing.T) {
 	vars, err := coll.EvaluateVariables()
 	assert.Nil(t, err)
 	assert.Equal(t, "foo", vars["A"])
-	assert.Equal(t, "bar {{A}}", vars["B"])
+	assert.Equal(t, "bar foo", vars["B"])
+}
+
+func TestEvaluateVariables_ResolvesReferencesToDynamicVariables(t *testing.T) {
+	coll := &Collection{
+		Variables: map[string]string{
+			"ID":   "{{$random.hexadecimal(4)}}",
+			"PATH": "/users/{{ID}}",
+			"URL":  "{{HOST}}{{PATH}}?again={{ID}}",
+			"HOST": "http://localhost",
+		},
+	}
+	vars, err := coll.EvaluateVariables()
+	assert.Nil(t, err)
+	assert.Equal(t, "http://localhost/users/"+vars["ID"]+"?again="+vars["ID"], vars["URL"])
+}
+
+func TestEvaluateVariables_ReportsCycles(t *testing.T) {
+	coll := &Collection{
+		Variables: map[string]string{
+			"a": "{{b}}",
+			"b": "x{{c}}",
+			"c": "{{a}}",
+		},
+	}
+	_, err := coll.EvaluateVariables()
+	var cycle *CycleError
+	assert.ErrorAs(t, err, &cycle)
+	assert.EqualError(t, err, "variable cycle: a -> b -> c -> a")
 }
 
 func TestEvaluateVariables_RandomFunction(t *testing.T) {
@@ -79,6 +108,77 @@ func TestEvaluateVariables_RandomFunction(t *testing.T) {
 	assert.Len(t, vars["R"], 4) // Should be 4 hex digits
 }
 
+func TestEvaluateVariables_DynamicVariables(t *testing.T) {
+	tests := []struct {
+		value   string
+		pattern string
+	}{
+		{"{{$uuid}}", `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`},
+		{"{{$random.uuid}}", `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`},
+		{"{{$random.uuid()}}", `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`},
+		{"{{$timestamp}}", `^\d{10}$`},
+		{"{{$isoTimestamp}}", `^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d{3}Z$`},
+		{"{{$randomInt}}", `^\d{1,3}$`},
+		{"{{$random.integer}}", `^\d{1,3}$`},
+		{"{{$random.integer(-5, -3)}}", `^-[45]$`},
+		{"{{ $random.float(1.5, 2) }}", `^1\.\d*$`},
+		{"{{$random.alphabetic(12)}}", `^[a-zA-Z]{12}$`},
+		{"{{$random.alphanumeric(12)}}", `^\w{12}$`},
+		{"{{$random.hexadecimal(12)}}", `^[0-9A-F]{12}$`},
+		{"{{$random.email}}", `^[a-z]+\.[a-z]+@example\.com$`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			coll := &Collection{Variables: map[string]string{"X": tt.value}}
+			vars, err := coll.EvaluateVariables()
+			assert.NoError(t, err)
+			assert.Regexp(t, tt.pattern, vars["X"])
+		})
+	}
+}
+
+func TestEvaluateVariablesWith_SameSeedYieldsSameValues(t *testing.T) {
+	coll := &Collection{Variables: map[string]string{
+		"A": "{{$uuid}}",
+		"B": "{{$random.alphanumeric(10)}}",
+		"C": "{{$random.integer(0, 1000000)}}",
+	}}
+
+	first, err := coll.EvaluateVariablesWith(random.New(7, 0))
+	assert.NoError(t, err)
+	second, err := coll.EvaluateVariablesWith(random.New(7, 0))
+	assert.NoError(t, err)
+	other, err := coll.EvaluateVariablesWith(random.New(7, 1))
+	assert.NoError(t, err)
+
+	assert.Equal(t, first, second)
+	assert.NotEqual(t, first, other)
+}
+
+func TestEvaluateVariables_InvalidDynamicVariables(t *testing.T) {
+	tests := []struct {
+		value string
+		err   string
+	}{
+		{"{{$unknown}}", "unknown dynamic variable '$unknown'"},
+		{"{{$uuid(1)}}", "dynamic variable '$uuid' takes no arguments"},
+		{"{{$random.integer(5)}}", "must be 'from, to'"},
+		{"{{$random.integer(5, 1)}}", "from must be less than to"},
+		{"{{$random.integer(1.5, 3)}}", "must be integers"},
+		{"{{$random.alphabetic}}", "invalid argument for random.alphabetic"},
+		{"{{$random.alphanumeric(0)}}", "length must be > 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			coll := &Collection{Variables: map[string]string{"X": tt.value}}
+			_, err := coll.EvaluateVariables()
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
 func TestEvaluateVariables_UnsupportedNamespace(t *testing.T) {
 	coll := &Collection{
 		Variables: map[string]string{
@@ -109,3 +209,70 @@ func TestEvaluateVariables_EmptyVariables(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Empty(t, vars)
 }
+
+func TestParseGRPCTarget(t *testing.T) {
+	tests := []struct {
+		url    string
+		target GRPCTarget
+		err    string
+	}{
+		{"localhost:9090/pkg.Service/Method", GRPCTarget{Address: "localhost:9090", Service: "pkg.Service", Method: "Method"}, ""},
+		{"grpc://localhost:9090/pkg.Service/Method", GRPCTarget{Address: "localhost:9090", Service: "pkg.Service", Method: "Method"}, ""},
+		{"grpcs://api.example.com:443/pkg.Service/Method", GRPCTarget{Address: "api.example.com:443", Service: "pkg.Service", Method: "Method", TLS: true}, ""},
+		{"https://api.example.com:443/pkg.Service/Method", GRPCTarget{Address: "api.example.com:443", Service: "pkg.Service", Method: "Method", TLS: true}, ""},
+		{"ws://localhost:9090/pkg.Service/Method", GRPCTarget{}, "unsupported scheme 'ws'"},
+		{"localhost:9090/pkg.Service", GRPCTarget{}, "expected '<host>:<port>/<package.Service>/<Method>'"},
+		{"localhost:9090/pkg.Service/Method/", GRPCTarget{}, "expected '<host>:<port>/<package.Service>/<Method>'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			target, err := ParseGRPCTarget(tt.url)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.target, target)
+			assert.Equal(t, "/pkg.Service/Method", target.FullMethod())
+		})
+	}
+}
+
+func TestScopeAt_LaterDeclarationsShadowEarlierOnes(t *testing.T) {
+	scoped := []ScopedVariable{
+		{Name: "ID", Value: "1", Request: 1},
+		{Name: "NAME", Value: "a", Request: 1},
+		{Name: "ID", Value: "2", Request: 2},
+	}
+
+	assert.Empty(t, ScopeAt(scoped, 0))
+	assert.Equal(t, map[string]string{"ID": "1", "NAME": "a"}, ScopeAt(scoped, 1))
+	assert.Equal(t, map[string]string{"ID": "2", "NAME": "a"}, ScopeAt(scoped, 3))
+}
+
+func TestEvaluateScopedVariables(t *testing.T) {
+	c := &Collection{ScopedVariables: []ScopedVariable{
+		{Name: "ID", Value: "{{$random.hexadecimal(4)}}", Request: 1},
+		{Name: "NAME", Value: "admin", Request: 2},
+		{Name: "NAME", Value: "{{NAME}}-{{ID}}@{{HOST}}/{{token}}", Request: 3},
+	}}
+
+	scoped, err := c.EvaluateScopedVariables(map[string]string{"HOST": "localhost"}, nil)
+
+	assert.NoError(t, err)
+	assert.Len(t, scoped, 3)
+	assert.Regexp(t, "^[0-9A-F]{4}$", scoped[0].Value)
+	assert.Equal(t, 1, scoped[0].Request)
+	assert.Equal(t, ScopedVariable{Name: "NAME", Value: "admin", Request: 2}, scoped[1])
+	assert.Equal(t, "admin-"+scoped[0].Value+"@localhost/{{token}}", scoped[2].Value)
+	assert.Equal(t, "{{$random.hexadecimal(4)}}", c.ScopedVariables[0].Value)
+}
+
+func TestResolveURL(t *testing.T) {
+	assert.True(t, IsRelativeURL("/users"))
+	assert.False(t, IsRelativeURL("http://localhost/users"))
+	assert.False(t, IsRelativeURL("{{host}}/users"))
+	assert.Equal(t, "http://localhost/api/users", ResolveURL("http://localhost/api/", "/users"))
+	assert.Equal(t, "http://localhost/api/users", ResolveURL("http://localhost/api", "/users"))
+}
@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severity classifies a Diagnostic.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic describes a problem found in a .http file. Line and Column are 1-based,
+// File is empty if the collection was not read from a file. Code identifies the kind
+// of problem for programs, Fix suggests how to resolve it.
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+	File     string   `json:"file,omitempty"`
+	Line     int      `json:"line"`
+	Column   int      `json:"column"`
+	Code     string   `json:"code"`
+	Message  string   `json:"message"`
+	Fix      string   `json:"fix,omitempty"`
+}
+
+func (d Diagnostic) Error() string {
+	kind := "parsing error"
+	if d.Severity == SeverityWarning {
+		kind = "warning"
+	}
+	msg := fmt.Sprintf("%s: %s at line %d, column %d", kind, d.Message, d.Line, d.Column)
+	if d.File != "" {
+		msg += " in " + d.File
+	}
+	return msg
+}
+
+// Diagnostics is a list of diagnostics. It is returned as error if it contains any errors.
+type Diagnostics []Diagnostic
+
+func (ds Diagnostics) Error() string {
+	msgs := make([]string, 0, len(ds))
+	for _, d := range ds {
+		msgs = append(msgs, d.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// HasErrors reports whether any of the diagnostics has error severity.
+func (ds Diagnostics) HasErrors() bool {
+	for _, d := range ds {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,193 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// context is the number of unchanged lines shown around a change.
+const context = 3
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+type op struct {
+	kind opKind
+	// a and b are the indexes of the line in the old and the new content.
+	a, b int
+}
+
+// Unified returns the unified diff of the old and the new content, or an empty
+// string if they are equal.
+func Unified(oldName, newName string, old, new []byte) string {
+	a, b := splitLines(string(old)), splitLines(string(new))
+	ops := edits(a, b)
+
+	var out strings.Builder
+	for _, h := range hunks(ops) {
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", oldName, newName)
+		}
+		writeHunk(&out, ops[h[0]:h[1]], a, b)
+	}
+	return out.String()
+}
+
+// splitLines splits s into lines, keeping their line endings.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// edits returns the shortest edit script turning a into b, using Myers' algorithm.
+func edits(a, b []string) []op {
+	n, m := len(a), len(b)
+	base := n + m + 1
+	v := make([]int, 2*base+1)
+	// trace holds the diagonals -d-1..d+1 of v at the start of each round d.
+	var trace [][]int
+
+	for d := 0; d < base; d++ {
+		trace = append(trace, append([]int(nil), v[base-d-1:base+d+2]...))
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[base+k-1] < v[base+k+1]) {
+				x = v[base+k+1]
+			} else {
+				x = v[base+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[base+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, n, m)
+			}
+		}
+	}
+	return nil
+}
+
+func backtrack(trace [][]int, n, m int) []op {
+	x, y := n, m
+	var ops []op
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		at := func(k int) int { return v[k+d+1] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, op{kind: opEqual, a: x, b: y})
+		}
+		if d > 0 {
+			if x == prevX {
+				ops = append(ops, op{kind: opInsert, a: x, b: prevY})
+			} else {
+				ops = append(ops, op{kind: opDelete, a: prevX, b: y})
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+// hunks returns the ranges of ops that are shown, each change with its surrounding context.
+func hunks(ops []op) [][2]int {
+	var result [][2]int
+	for i := 0; i < len(ops); i++ {
+		if ops[i].kind == opEqual {
+			continue
+		}
+		start := max(i-context, 0)
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != opEqual {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == opEqual {
+				run++
+			}
+			if run == len(ops) || run-end > 2*context {
+				end = min(end+context, len(ops))
+				break
+			}
+			end = run
+		}
+		result = append(result, [2]int{start, end})
+		i = end
+	}
+	return result
+}
+
+func writeHunk(out *strings.Builder, ops []op, a, b []string) {
+	oldStart, newStart := ops[0].a, ops[0].b
+	oldLen, newLen := 0, 0
+	for _, o := range ops {
+		if o.kind != opInsert {
+			oldLen++
+		}
+		if o.kind != opDelete {
+			newLen++
+		}
+	}
+	fmt.Fprintf(out, "@@ -%s +%s @@\n", hunkRange(oldStart, oldLen), hunkRange(newStart, newLen))
+
+	for _, o := range ops {
+		switch o.kind {
+		case opEqual:
+			writeLine(out, " ", a[o.a])
+		case opDelete:
+			writeLine(out, "-", a[o.a])
+		case opInsert:
+			writeLine(out, "+", b[o.b])
+		}
+	}
+}
+
+func hunkRange(start, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if length == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, length)
+}
+
+func writeLine(out *strings.Builder, prefix, line string) {
+	out.WriteString(prefix)
+	out.WriteString(line)
+	if !strings.HasSuffix(line, "\n") {
+		out.WriteString("\n\\ No newline at end of file\n")
+	}
+}
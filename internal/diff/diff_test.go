@@ -0,0 +1,73 @@
+package diff
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestUnified_ShouldReturnEmptyDiffForEqualContent(t *testing.T) {
+	assert.Empty(t, Unified("a", "b", []byte("x\ny\n"), []byte("x\ny\n")))
+}
+
+func TestUnified_ShouldShowChangesWithContext(t *testing.T) {
+	old := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	new := "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n"
+
+	got := Unified("a.http", "a.http (formatted)", []byte(old), []byte(new))
+
+	assert.Equal(t, strings.Join([]string{
+		"--- a.http",
+		"+++ a.http (formatted)",
+		"@@ -1,6 +1,6 @@",
+		" 1",
+		" 2",
+		"-3",
+		"+three",
+		" 4",
+		" 5",
+		" 6",
+		"@@ -10,3 +10,4 @@",
+		" 10",
+		" 11",
+		" 12",
+		"+13",
+		"",
+	}, "\n"), got)
+}
+
+func TestUnified_ShouldMarkMissingNewline(t *testing.T) {
+	got := Unified("a", "b", []byte("x"), []byte("x\n"))
+
+	assert.Equal(t, "--- a\n+++ b\n@@ -1 +1 @@\n-x\n\\ No newline at end of file\n+x\n", got)
+}
+
+func TestUnified_ShouldHandleEmptyContent(t *testing.T) {
+	assert.Equal(t, "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+x\n+y\n", Unified("a", "b", nil, []byte("x\ny\n")))
+}
+
+func TestEdits_ShouldTransformOldIntoNew(t *testing.T) {
+	tests := [][2]string{
+		{"a b c a b b a", "c b a b a c"},
+		{"", "x y"},
+		{"x y", ""},
+		{"x y z", "x y z"},
+		{"1 2 3 4 5", "0 1 3 5 6"},
+	}
+	for _, tt := range tests {
+		a, b := strings.Fields(tt[0]), strings.Fields(tt[1])
+
+		gotA, gotB := []string{}, []string{}
+		for _, o := range edits(a, b) {
+			if o.kind != opInsert {
+				gotA = append(gotA, a[o.a])
+			}
+			if o.kind != opDelete {
+				gotB = append(gotB, b[o.b])
+			}
+		}
+
+		assert.Equal(t, a, gotA, tt)
+		assert.Equal(t, b, gotB, tt)
+	}
+}
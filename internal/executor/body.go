@@ -0,0 +1,246 @@
+package executor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"github.com/fdrolshagen/jetter/internal"
+	"github.com/fdrolshagen/jetter/internal/random"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"strings"
+)
+
+// maxPlaceholderLength limits how far ahead the templateReader looks for
+// the closing braces of a placeholder.
+const maxPlaceholderLength = 256
+
+// newHttpRequest creates the HTTP request for r. Inline bodies are sent from memory,
+// file and multipart bodies are built and streamed for every request, so large files
+// are never held in memory. Variables in file bodies are substituted while streaming, their
+// dynamic variables take random values from bodySource.
+func newHttpRequest(ctx context.Context, r internal.Request, vars map[string]string, bodySource sourceFunc) (*http.Request, error) {
+	var open func() (io.ReadCloser, int64, error)
+	switch {
+	case r.GraphQL != nil:
+		return newGraphQLRequest(ctx, r)
+	case r.Multipart != nil:
+		open = func() (io.ReadCloser, int64, error) {
+			return openMultipartBody(*r.Multipart, vars, bodySource.source())
+		}
+	case r.BodyFile != nil:
+		open = func() (io.ReadCloser, int64, error) {
+			f, err := openBodyFile(*r.BodyFile, vars, bodySource.source())
+			if err != nil {
+				return nil, 0, err
+			}
+			return f, fileLength(f), nil
+		}
+	default:
+		return http.NewRequestWithContext(ctx, r.Method, r.Url, bytes.NewBufferString(r.Body))
+	}
+
+	body, length, err := open()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, r.Method, r.Url, body)
+	if err != nil {
+		body.Close()
+		return nil, err
+	}
+	req.GetBody = func() (io.ReadCloser, error) {
+		body, _, err := open()
+		return body, err
+	}
+	if length >= 0 {
+		req.ContentLength = length
+	}
+	return req, nil
+}
+
+// sourceFunc creates the random source of a request body. Every source it creates generates
+// the same values, so that a body read again for a retry or redirect is the same. A nil
+// sourceFunc creates nil sources, which generate unpredictable values.
+type sourceFunc func() *random.Source
+
+func (f sourceFunc) source() *random.Source {
+	if f == nil {
+		return nil
+	}
+	return f()
+}
+
+func openBodyFile(bf internal.BodyFile, vars map[string]string, src *random.Source) (io.ReadCloser, error) {
+	f, err := os.Open(bf.Path)
+	if err != nil {
+		return nil, err
+	}
+	if bf.Raw {
+		return f, nil
+	}
+	return &templateReader{src: bufio.NewReader(f), closer: f, vars: vars, random: src}, nil
+}
+
+// templateReader replaces {{name}} placeholders of known variables and dynamic variables
+// while reading from src. Placeholders of unknown variables are passed through unchanged.
+type templateReader struct {
+	src     *bufio.Reader
+	closer  io.Closer
+	vars    map[string]string
+	random  *random.Source
+	pending []byte
+}
+
+func (t *templateReader) Read(p []byte) (int, error) {
+	for len(t.pending) == 0 {
+		if err := t.fill(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, t.pending)
+	t.pending = t.pending[n:]
+	return n, nil
+}
+
+func (t *templateReader) Close() error {
+	return t.closer.Close()
+}
+
+func (t *templateReader) fill() error {
+	chunk, err := t.src.ReadSlice('{')
+	t.pending = append(t.pending[:0], chunk...)
+	if err == bufio.ErrBufferFull || (err == io.EOF && len(chunk) > 0) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if next, err := t.src.Peek(1); err != nil || next[0] != '{' {
+		return nil
+	}
+
+	ahead, _ := t.src.Peek(maxPlaceholderLength)
+	end := bytes.Index(ahead[1:], []byte("}}"))
+	if end < 0 {
+		return nil
+	}
+
+	name := string(ahead[1 : end+1])
+	placeholder := "{{" + name + "}}"
+	var value string
+	var ok bool
+	if ref, isRef := internal.ReferenceName(placeholder); isRef {
+		value, ok = t.vars[ref]
+	}
+	if strings.HasPrefix(strings.TrimSpace(name), "$") {
+		var err error
+		if value, err = internal.EvaluateFunctions(placeholder, t.random); err != nil {
+			return err
+		}
+		ok = value != placeholder
+	}
+	if !ok {
+		return nil
+	}
+
+	_, _ = t.src.Discard(end + 3)
+	t.pending = append(t.pending[:len(t.pending)-1], value...)
+	return nil
+}
+
+// openMultipartBody builds the multipart body from its parts. File parts are opened
+// and streamed from disk. The returned length is -1 if it is not known in advance,
+// which is the case if any file part is subject to variable substitution.
+func openMultipartBody(mp internal.MultipartBody, vars map[string]string, src *random.Source) (io.ReadCloser, int64, error) {
+	var framing bytes.Buffer
+	w := multipart.NewWriter(&framing)
+	if err := w.SetBoundary(mp.Boundary); err != nil {
+		return nil, 0, err
+	}
+
+	body := &multiReadCloser{}
+	var readers []io.Reader
+	var length int64
+	add := func(r io.Reader, n int64) {
+		readers = append(readers, r)
+		if length >= 0 && n >= 0 {
+			length += n
+		} else {
+			length = -1
+		}
+	}
+	flush := func() {
+		b := bytes.Clone(framing.Bytes())
+		framing.Reset()
+		add(bytes.NewReader(b), int64(len(b)))
+	}
+
+	for _, part := range mp.Parts {
+		header := make(textproto.MIMEHeader, len(part.Headers))
+		for k, v := range part.Headers {
+			header.Set(k, v)
+		}
+		if _, err := w.CreatePart(header); err != nil {
+			body.Close()
+			return nil, 0, err
+		}
+		flush()
+
+		if part.File == nil {
+			add(strings.NewReader(part.Content), int64(len(part.Content)))
+			continue
+		}
+
+		f, err := openBodyFile(*part.File, vars, src)
+		if err != nil {
+			body.Close()
+			return nil, 0, err
+		}
+		body.closers = append(body.closers, f)
+		add(f, fileLength(f))
+	}
+
+	if err := w.Close(); err != nil {
+		body.Close()
+		return nil, 0, err
+	}
+	flush()
+
+	body.Reader = io.MultiReader(readers...)
+	return body, length, nil
+}
+
+// fileLength returns the size of raw file bodies and -1 for everything else.
+func fileLength(r io.Reader) int64 {
+	f, ok := r.(*os.File)
+	if !ok {
+		return -1
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return -1
+	}
+	return info.Size()
+}
+
+// multiReadCloser reads the concatenated parts of a body and closes all opened files.
+type multiReadCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (m *multiReadCloser) Close() error {
+	var err error
+	for _, c := range m.closers {
+		if cerr := c.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
@@ -0,0 +1,144 @@
+package executor
+
+import (
+	"bufio"
+	"context"
+	"github.com/fdrolshagen/jetter/internal"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func readTemplate(t *testing.T, input string, vars map[string]string, bufSize int) string {
+	r := &templateReader{
+		src:    bufio.NewReaderSize(strings.NewReader(input), bufSize),
+		closer: io.NopCloser(nil),
+		vars:   vars,
+	}
+	out, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	return string(out)
+}
+
+func TestTemplateReader(t *testing.T) {
+	vars := map[string]string{"ID": "123", "NAME": "foo"}
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"no placeholders", `{"a": {"b": 1}}`, `{"a": {"b": 1}}`},
+		{"known variables", `{"id": "{{ID}}", "name": "{{NAME}}"}`, `{"id": "123", "name": "foo"}`},
+		{"unknown variable", `{"id": "{{OTHER}}"}`, `{"id": "{{OTHER}}"}`},
+		{"spaces around the name", `{"id": "{{ ID }}"}`, `{"id": "{{ ID }}"}`},
+		{"adjacent placeholders", `{{ID}}{{NAME}}`, `123foo`},
+		{"unterminated placeholder", `{{ID`, `{{ID`},
+		{"trailing brace", `x{`, `x{`},
+		{"empty input", ``, ``},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, readTemplate(t, tt.input, vars, 4096))
+		})
+	}
+}
+
+func TestTemplateReader_EvaluatesDynamicVariables(t *testing.T) {
+	out := readTemplate(t, `{{$uuid}} {{ $random.integer(1, 2) }} {{$uuid}}`, nil, 4096)
+
+	parts := strings.Split(out, " ")
+	assert.Len(t, parts, 3)
+	assert.Len(t, parts[0], 36)
+	assert.Equal(t, "1", parts[1])
+	assert.NotEqual(t, parts[0], parts[2])
+
+	r := &templateReader{src: bufio.NewReader(strings.NewReader(`{{$unknown}}`)), closer: io.NopCloser(nil)}
+	_, err := io.ReadAll(r)
+	assert.EqualError(t, err, "unknown dynamic variable '$unknown'")
+}
+
+func TestTemplateReader_LargeInput(t *testing.T) {
+	input := strings.Repeat("abcdefgh", 10000) + "{{ID}}" + strings.Repeat("{x}", 1000)
+	want := strings.Repeat("abcdefgh", 10000) + "123" + strings.Repeat("{x}", 1000)
+
+	assert.Equal(t, want, readTemplate(t, input, map[string]string{"ID": "123"}, 16))
+}
+
+func TestNewHttpRequest_GetBodyRepeatsDynamicValues(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "body.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"id": "{{$uuid}}", "n": {{$random.integer}}}`), 0644))
+	r := internal.Request{Method: "POST", Url: "http://localhost", BodyFile: &internal.BodyFile{Path: path}}
+
+	req, err := newHttpRequest(context.Background(), r, nil, newBodySource(42, 0, 0, 0))
+	assert.NoError(t, err)
+	first, err := io.ReadAll(req.Body)
+	assert.NoError(t, err)
+	again, err := req.GetBody()
+	assert.NoError(t, err)
+	second, err := io.ReadAll(again)
+	assert.NoError(t, err)
+
+	assert.Equal(t, string(first), string(second))
+	assert.NotContains(t, string(first), "{{")
+}
+
+func TestOpenMultipartBody(t *testing.T) {
+	dir := t.TempDir()
+	raw := filepath.Join(dir, "image.png")
+	templated := filepath.Join(dir, "data.json")
+	assert.NoError(t, os.WriteFile(raw, []byte{0x89, 'P', 'N', 'G'}, 0644))
+	assert.NoError(t, os.WriteFile(templated, []byte(`{"id": "{{ID}}"}`), 0644))
+
+	mp := internal.MultipartBody{
+		Boundary: "abc",
+		Parts: []internal.MultipartPart{
+			{Headers: map[string]string{"Content-Disposition": `form-data; name="name"`}, Content: "foo"},
+			{Headers: map[string]string{"Content-Disposition": `form-data; name="data"; filename="data.json"`}, File: &internal.BodyFile{Path: templated}},
+			{Headers: map[string]string{"Content-Disposition": `form-data; name="image"; filename="image.png"`}, File: &internal.BodyFile{Path: raw, Raw: true}},
+		},
+	}
+
+	body, length, err := openMultipartBody(mp, map[string]string{"ID": "123"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(-1), length)
+	defer body.Close()
+
+	form, err := multipart.NewReader(body, "abc").ReadForm(1 << 20)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"foo"}, form.Value["name"])
+	assert.Equal(t, "data.json", form.File["data"][0].Filename)
+	assert.Equal(t, "image.png", form.File["image"][0].Filename)
+
+	f, err := form.File["data"][0].Open()
+	assert.NoError(t, err)
+	content, _ := io.ReadAll(f)
+	assert.Equal(t, `{"id": "123"}`, string(content))
+}
+
+func TestOpenMultipartBody_KnownLength(t *testing.T) {
+	dir := t.TempDir()
+	raw := filepath.Join(dir, "image.png")
+	assert.NoError(t, os.WriteFile(raw, []byte("0123456789"), 0644))
+
+	mp := internal.MultipartBody{
+		Boundary: "abc",
+		Parts: []internal.MultipartPart{
+			{Headers: map[string]string{"Content-Disposition": `form-data; name="name"`}, Content: "foo"},
+			{Headers: map[string]string{"Content-Disposition": `form-data; name="image"; filename="image.png"`}, File: &internal.BodyFile{Path: raw, Raw: true}},
+		},
+	}
+
+	body, length, err := openMultipartBody(mp, nil, nil)
+	assert.NoError(t, err)
+	defer body.Close()
+
+	content, err := io.ReadAll(body)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(content)), length)
+}
@@ -0,0 +1,56 @@
+package executor
+
+import (
+	"fmt"
+	"github.com/fdrolshagen/jetter/internal"
+	"net/http"
+)
+
+// transports maps the protocol version of a request line to the transport used to send it.
+// Requests without a version use the default transport, which negotiates HTTP/2 via ALPN.
+var transports = map[string]http.RoundTripper{
+	"":              http.DefaultTransport,
+	internal.HTTP11: newTransport(http1Only()),
+	internal.HTTP2:  newTransport(http2Only()),
+}
+
+func newTransport(protocols *http.Protocols) http.RoundTripper {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Protocols = protocols
+	return transport
+}
+
+// http1Only disables HTTP/2 negotiation.
+func http1Only() *http.Protocols {
+	p := new(http.Protocols)
+	p.SetHTTP1(true)
+	return p
+}
+
+// http2Only forces HTTP/2, using h2c with prior knowledge for plain-text URLs.
+func http2Only() *http.Protocols {
+	p := new(http.Protocols)
+	p.SetHTTP2(true)
+	p.SetUnencryptedHTTP2(true)
+	return p
+}
+
+// clientFor returns a client for r that honours its protocol version and options.
+// The cookie jar is shared by all requests of an execution and may be nil.
+func clientFor(r internal.Request, jar http.CookieJar) (*http.Client, error) {
+	transport, ok := transports[r.HttpVersion]
+	if !ok {
+		return nil, fmt.Errorf("unsupported HTTP version: %s", r.HttpVersion)
+	}
+
+	client := &http.Client{Transport: transport}
+	if !r.Options.NoCookieJar {
+		client.Jar = jar
+	}
+	if r.Options.NoRedirect {
+		client.CheckRedirect = func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+	return client, nil
+}
@@ -0,0 +1,198 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"github.com/bufbuild/protocompile"
+	"github.com/fdrolshagen/jetter/internal"
+	"google.golang.org/grpc"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// descriptors caches the resolved files by their source, so that the .proto files of a scenario
+// are compiled and a server is asked by reflection only once. Failures are not cached.
+var descriptors sync.Map
+
+// findMethod resolves the descriptor of the method called by a gRPC request. The descriptors are
+// taken from the given .proto files and descriptor sets, or from the server by reflection.
+func findMethod(ctx context.Context, conn *grpc.ClientConn, protos []string, target internal.GRPCTarget) (protoreflect.MethodDescriptor, error) {
+	key := "reflection:" + target.Address + "/" + target.Service
+	if len(protos) > 0 {
+		key = "protos:" + strings.Join(protos, "\x00")
+	}
+
+	var files *protoregistry.Files
+	if cached, ok := descriptors.Load(key); ok {
+		files = cached.(*protoregistry.Files)
+	} else {
+		var err error
+		if len(protos) > 0 {
+			files, err = loadProtos(ctx, protos)
+		} else {
+			files, err = reflectFiles(ctx, conn, target.Service)
+		}
+		if err != nil {
+			return nil, err
+		}
+		descriptors.Store(key, files)
+	}
+
+	d, err := files.FindDescriptorByName(protoreflect.FullName(target.Service))
+	if err != nil {
+		return nil, fmt.Errorf("gRPC service '%s' not found", target.Service)
+	}
+	service, ok := d.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("'%s' is not a gRPC service", target.Service)
+	}
+	method := service.Methods().ByName(protoreflect.Name(target.Method))
+	if method == nil {
+		return nil, fmt.Errorf("gRPC method '%s' not found in service '%s'", target.Method, target.Service)
+	}
+	return method, nil
+}
+
+// loadProtos compiles the .proto files and reads the binary descriptor sets. Imports of a .proto
+// file are resolved relative to its directory, well-known types are always available.
+func loadProtos(ctx context.Context, protos []string) (*protoregistry.Files, error) {
+	files := &protoregistry.Files{}
+	for _, path := range protos {
+		if filepath.Ext(path) != ".proto" {
+			if err := loadDescriptorSet(path, files); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		compiler := protocompile.Compiler{
+			Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+				ImportPaths: []string{filepath.Dir(path)},
+			}),
+		}
+		compiled, err := compiler.Compile(ctx, filepath.Base(path))
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile '%s': %w", path, err)
+		}
+		for _, fd := range compiled {
+			if err := register(files, fd); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return files, nil
+}
+
+// loadDescriptorSet reads a binary FileDescriptorSet, as written by `protoc --descriptor_set_out`.
+func loadDescriptorSet(path string, files *protoregistry.Files) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	set := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(data, set); err != nil {
+		return fmt.Errorf("invalid descriptor set '%s': %w", path, err)
+	}
+	fromSet, err := protodesc.NewFiles(set)
+	if err != nil {
+		return fmt.Errorf("invalid descriptor set '%s': %w", path, err)
+	}
+
+	var registerErr error
+	fromSet.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		registerErr = register(files, fd)
+		return registerErr == nil
+	})
+	return registerErr
+}
+
+// register adds the file and its imports to files, unless they are already known.
+func register(files *protoregistry.Files, fd protoreflect.FileDescriptor) error {
+	if _, err := files.FindFileByPath(fd.Path()); err == nil {
+		return nil
+	}
+	if err := files.RegisterFile(fd); err != nil {
+		return err
+	}
+	imports := fd.Imports()
+	for i := 0; i < imports.Len(); i++ {
+		if err := register(files, imports.Get(i).FileDescriptor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reflectFiles asks the server for the file defining the service and its dependencies,
+// using the v1 server reflection protocol. Well-known types the server does not send are
+// taken from the types linked into jetter.
+func reflectFiles(ctx context.Context, conn *grpc.ClientConn, service string) (*protoregistry.Files, error) {
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("server reflection failed: %w", err)
+	}
+	defer stream.CloseSend()
+
+	set := &descriptorpb.FileDescriptorSet{}
+	// received holds the files in set, requested the files asked for by name.
+	received, requested := make(map[string]bool), make(map[string]bool)
+	pending := []*reflectionpb.ServerReflectionRequest{{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: service},
+	}}
+	for len(pending) > 0 {
+		if err := stream.Send(pending[0]); err != nil {
+			return nil, fmt.Errorf("server reflection failed: %w", err)
+		}
+		pending = pending[1:]
+		resp, err := stream.Recv()
+		if err != nil {
+			return nil, fmt.Errorf("server reflection failed: %w", err)
+		}
+		if e := resp.GetErrorResponse(); e != nil {
+			return nil, fmt.Errorf("server reflection failed for '%s': %s", service, e.GetErrorMessage())
+		}
+
+		for _, raw := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+			fdp := &descriptorpb.FileDescriptorProto{}
+			if err := proto.Unmarshal(raw, fdp); err != nil {
+				return nil, fmt.Errorf("server reflection returned an invalid descriptor: %w", err)
+			}
+			if received[fdp.GetName()] {
+				continue
+			}
+			received[fdp.GetName()] = true
+			set.File = append(set.File, fdp)
+		}
+
+		for i := 0; i < len(set.File); i++ {
+			for _, dep := range set.File[i].GetDependency() {
+				if received[dep] || requested[dep] {
+					continue
+				}
+				if fd, err := protoregistry.GlobalFiles.FindFileByPath(dep); err == nil {
+					received[dep] = true
+					set.File = append(set.File, protodesc.ToFileDescriptorProto(fd))
+					continue
+				}
+				requested[dep] = true
+				pending = append(pending, &reflectionpb.ServerReflectionRequest{
+					MessageRequest: &reflectionpb.ServerReflectionRequest_FileByFilename{FileByFilename: dep},
+				})
+			}
+		}
+	}
+
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("server reflection returned invalid descriptors: %w", err)
+	}
+	return files, nil
+}
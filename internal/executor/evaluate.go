@@ -1,7 +1,11 @@
 package executor
 
 import (
+	"fmt"
 	"github.com/fdrolshagen/jetter/internal"
+	"github.com/fdrolshagen/jetter/internal/random"
+	"maps"
+	"slices"
 	"strings"
 )
 
@@ -10,28 +14,123 @@ func Evaluate(c *internal.Collection) ([]internal.Request, error) {
 	if err != nil {
 		return nil, err
 	}
+	scoped, err := c.EvaluateScopedVariables(vars, nil)
+	if err != nil {
+		return nil, err
+	}
 
 	requests := make([]internal.Request, 0, len(c.Requests))
-	for _, req := range c.Requests {
-		newReq := req
-		newReq.Url = replaceVariablesInString(newReq.Url, vars)
-		newReq.Body = replaceVariablesInString(newReq.Body, vars)
-		newHeaders := make(map[string]string, len(newReq.Headers))
-		for hk, hv := range newReq.Headers {
-			newHeaders[hk] = replaceVariablesInString(hv, vars)
+	for i, req := range c.Requests {
+		scope, err := requestVariables(vars, scoped, i, nil, req.Variables)
+		if err != nil {
+			return nil, err
+		}
+		evaluated, err := evaluateRequest(req, scope, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error in request '%s': %w", req.Name, err)
 		}
-		newReq.Headers = newHeaders
-		requests = append(requests, newReq)
+		requests = append(requests, evaluated)
 	}
 
 	return requests, nil
 }
 
-func replaceVariablesInString(input string, vars map[string]string) string {
-	result := input
-	for k, v := range vars {
-		placeholder := "{{" + k + "}}"
-		result = strings.ReplaceAll(result, placeholder, v)
+// evaluateRequest substitutes the variables within the request and evaluates its dynamic
+// variables. Every dynamic variable written in the request gets a value of its own, while
+// variables keep the value they got for the execution. Random values are taken from src,
+// in the order of the request's parts, so that a seeded source yields the same request.
+func evaluateRequest(req internal.Request, vars map[string]string, src *random.Source) (internal.Request, error) {
+	e := evaluator{vars: vars, random: src}
+	newReq := req
+	newReq.Url = e.evaluate(newReq.Url)
+	newReq.Body = e.evaluate(newReq.Body)
+	newHeaders := make(map[string]string, len(newReq.Headers))
+	for _, hk := range slices.Sorted(maps.Keys(newReq.Headers)) {
+		newHeaders[hk] = e.evaluate(newReq.Headers[hk])
+	}
+	newReq.Headers = newHeaders
+	if req.Multipart != nil {
+		newReq.Multipart = evaluateMultipart(*req.Multipart, &e)
+	}
+	if req.GraphQL != nil {
+		newReq.GraphQL = &internal.GraphQL{
+			Query:         e.evaluate(req.GraphQL.Query),
+			OperationName: e.evaluate(req.GraphQL.OperationName),
+			Variables:     e.evaluate(req.GraphQL.Variables),
+		}
+	}
+	if req.WebSocket != nil {
+		steps := make([]internal.WebSocketStep, 0, len(req.WebSocket.Steps))
+		for _, step := range req.WebSocket.Steps {
+			step.Message = e.evaluate(step.Message)
+			steps = append(steps, step)
+		}
+		newReq.WebSocket = &internal.WebSocket{Steps: steps}
+	}
+	return newReq, e.err
+}
+
+// evaluator substitutes variables and evaluates dynamic variables within the strings of
+// a request. Variables are substituted first, so they may be used as arguments, e.g.
+// `{{$random.integer(1, {{MAX}})}}`. The first error is kept in err.
+type evaluator struct {
+	vars   map[string]string
+	random *random.Source
+	err    error
+}
+
+func (e *evaluator) evaluate(s string) string {
+	s = replaceVariablesInString(s, e.vars)
+	result, err := internal.EvaluateFunctions(s, e.random)
+	if err != nil {
+		if e.err == nil {
+			e.err = err
+		}
+		return s
 	}
 	return result
 }
+
+// resolveUrl resolves a relative request URL against the base URL of the scenario or,
+// if none is given, the `baseUrl` variable. The scheme of the base URL is mapped to
+// ws or wss for WebSocket requests. gRPC targets are never resolved.
+func resolveUrl(r *internal.Request, base string, vars map[string]string) error {
+	if r.GRPC != nil || !internal.IsRelativeURL(r.Url) {
+		return nil
+	}
+	if base == "" {
+		base = vars[internal.BaseURLVariable]
+	}
+	if base == "" {
+		return fmt.Errorf("relative URL '%s' needs a base URL, set --base-url, '#@jetter base-url' or the '%s' variable",
+			r.Url, internal.BaseURLVariable)
+	}
+	base = replaceVariablesInString(base, vars)
+	if r.WebSocket != nil {
+		if rest, ok := strings.CutPrefix(base, "http"); ok {
+			base = "ws" + rest
+		}
+	}
+	r.Url = internal.ResolveURL(base, r.Url)
+	return nil
+}
+
+func evaluateMultipart(mp internal.MultipartBody, e *evaluator) *internal.MultipartBody {
+	parts := make([]internal.MultipartPart, 0, len(mp.Parts))
+	for _, part := range mp.Parts {
+		newPart := part
+		newPart.Content = e.evaluate(part.Content)
+		newPart.Headers = make(map[string]string, len(part.Headers))
+		for _, hk := range slices.Sorted(maps.Keys(part.Headers)) {
+			newPart.Headers[hk] = e.evaluate(part.Headers[hk])
+		}
+		parts = append(parts, newPart)
+	}
+	return &internal.MultipartBody{Boundary: mp.Boundary, Parts: parts}
+}
+
+// replaceVariablesInString replaces the placeholders of the given variables in a single
+// pass, so placeholders within the substituted values are kept as they are.
+func replaceVariablesInString(input string, vars map[string]string) string {
+	return internal.SubstituteReferences(input, vars)
+}
@@ -3,6 +3,7 @@ package executor
 import (
 	"github.com/fdrolshagen/jetter/internal"
 	"github.com/stretchr/testify/assert"
+	"strings"
 	"testing"
 )
 
@@ -69,3 +70,91 @@ func TestEvaluate_EmptyCollection(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Len(t, requests, 0)
 }
+
+func TestEvaluate_RequestVariablesTakePrecedence(t *testing.T) {
+	c := &internal.Collection{
+		Variables: map[string]string{"USER": "bob", "HOST": "http://localhost"},
+		Requests: []internal.Request{
+			{Method: "GET", Url: "{{HOST}}/users/{{USER}}", Variables: map[string]string{"USER": "alice"}},
+			{Method: "GET", Url: "{{HOST}}/users/{{USER}}"},
+		},
+	}
+
+	requests, err := Evaluate(c)
+	assert.Nil(t, err)
+	assert.Equal(t, "http://localhost/users/alice", requests[0].Url)
+	assert.Equal(t, "http://localhost/users/bob", requests[1].Url)
+}
+
+func TestEvaluate_AppliesScopedVariables(t *testing.T) {
+	c := &internal.Collection{
+		Variables: map[string]string{"USER": "bob"},
+		Requests: []internal.Request{
+			{Method: "GET", Url: "/users/{{USER}}"},
+			{Method: "GET", Url: "/users/{{USER}}"},
+			{Method: "GET", Url: "/users/{{USER}}", Variables: map[string]string{"USER": "carol"}},
+		},
+		ScopedVariables: []internal.ScopedVariable{{Name: "USER", Value: "alice", Request: 1}},
+	}
+
+	requests, err := Evaluate(c)
+	assert.Nil(t, err)
+	assert.Equal(t, "/users/bob", requests[0].Url)
+	assert.Equal(t, "/users/alice", requests[1].Url)
+	assert.Equal(t, "/users/carol", requests[2].Url)
+}
+
+func TestEvaluate_ReplacesVariablesInGraphQL(t *testing.T) {
+	c := &internal.Collection{
+		Variables: map[string]string{"ID": "42", "OP": "GetUser"},
+		Requests: []internal.Request{{
+			GraphQL: &internal.GraphQL{Query: "query {{OP}} { user(id: {{ID}}) { name } }", OperationName: "{{OP}}", Variables: `{"id": {{ID}}}`},
+		}},
+	}
+
+	requests, err := Evaluate(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &internal.GraphQL{
+		Query:         "query GetUser { user(id: 42) { name } }",
+		OperationName: "GetUser",
+		Variables:     `{"id": 42}`,
+	}, requests[0].GraphQL)
+	assert.Equal(t, `{"id": {{ID}}}`, c.Requests[0].GraphQL.Variables)
+}
+
+func TestEvaluate_DynamicVariablesPerOccurrence(t *testing.T) {
+	c := &internal.Collection{
+		Variables: map[string]string{"ID": "{{$uuid}}", "MAX": "2"},
+		Requests: []internal.Request{
+			{
+				Name:    "Create",
+				Method:  "POST",
+				Url:     "http://localhost/{{ID}}/{{ID}}/{{$uuid}}/{{$uuid}}",
+				Headers: map[string]string{"X-Request-Id": "{{$uuid}}"},
+				Body:    `{"n": {{$random.integer(1, {{MAX}})}}, "at": "{{$isoTimestamp}}"}`,
+			},
+		},
+	}
+
+	requests, err := Evaluate(c)
+
+	assert.NoError(t, err)
+	segments := strings.Split(strings.TrimPrefix(requests[0].Url, "http://localhost/"), "/")
+	assert.Len(t, segments, 4)
+	assert.Equal(t, segments[0], segments[1], "a variable keeps its value")
+	assert.NotEqual(t, segments[1], segments[2], "inline dynamic variables get their own value")
+	assert.NotEqual(t, segments[2], segments[3], "every occurrence gets its own value")
+	assert.Len(t, requests[0].Headers["X-Request-Id"], 36)
+	assert.Regexp(t, `^\{"n": 1, "at": "\d{4}-.+Z"}$`, requests[0].Body)
+}
+
+func TestEvaluate_ErrorOnInvalidDynamicVariable(t *testing.T) {
+	c := &internal.Collection{
+		Requests: []internal.Request{{Name: "Users", Method: "GET", Url: "http://localhost/{{$random.integer(5, 1)}}"}},
+	}
+
+	_, err := Evaluate(c)
+
+	assert.ErrorContains(t, err, "error in request 'Users': invalid arguments for random.integer")
+}
@@ -1,10 +1,17 @@
 package executor
 
 import (
-	"bytes"
 	"context"
+	"encoding/binary"
+	"fmt"
 	"github.com/fdrolshagen/jetter/internal"
+	"github.com/fdrolshagen/jetter/internal/random"
+	"github.com/fdrolshagen/jetter/internal/script"
+	"hash/fnv"
+	"io"
+	"mime"
 	"net/http"
+	"net/http/cookiejar"
 	"sync"
 	"time"
 )
@@ -16,12 +23,18 @@ import (
 // and they continue until the duration elapses or the context is canceled.
 //
 // The function aggregates the results of all executions and indicates whether any of them encountered an error.
+//
+// Every execution takes its random values from a source derived from the scenario's seed,
+// the worker and the number of the execution within the worker. Runs with the same seed
+// and concurrency thus send the same data.
 func Submit(s internal.Scenario) internal.Result {
+	out := newOutputWriter(s.OutputPolicy)
 	if s.Duration == 0 {
-		execution := ExecuteScenario(context.Background(), s)
+		execution := executeScenario(context.Background(), s, out, 0, 0)
 		return internal.Result{
 			Executions: []internal.Execution{execution},
 			AnyError:   execution.AnyError,
+			Seed:       s.Seed,
 		}
 	}
 
@@ -40,12 +53,12 @@ func Submit(s internal.Scenario) internal.Result {
 	for i := 0; i < numWorkers; i++ {
 		go func() {
 			defer wg.Done()
-			for {
+			for iteration := 0; ; iteration++ {
 				select {
 				case <-ctx.Done():
 					return
 				default:
-					resultsCh <- ExecuteScenario(ctx, s)
+					resultsCh <- executeScenario(ctx, s, out, i, iteration)
 					time.Sleep(10 * time.Millisecond)
 				}
 			}
@@ -57,7 +70,7 @@ func Submit(s internal.Scenario) internal.Result {
 		close(resultsCh)
 	}()
 
-	var result internal.Result
+	result := internal.Result{Seed: s.Seed}
 	for execution := range resultsCh {
 		result.Executions = append(result.Executions, execution)
 		if execution.AnyError {
@@ -71,13 +84,32 @@ func Submit(s internal.Scenario) internal.Result {
 // ExecuteScenario executes all requests defined by the given scenario within the provided context.
 //
 // The scenario may include multiple requests, which are executed sequentially in order.
+// Each request is repeated according to its weight and followed by its think time.
 // The provided context `ctx` is used for cancellation and timeout; if the context is done,
 // in-progress requests will be interrupted.
 //
+// Relative request URLs are resolved against the base URL of the scenario or the
+// `baseUrl` variable.
+//
+// Global variables set by response handler scripts and cookies are scoped to a single
+// execution. Variables declared between requests shadow the collection variables from their
+// declaration on. Globals take precedence over both for all subsequent requests, variable
+// overrides of a request take precedence over all of them.
+//
 // The returned Execution summarizes the results of all requests and indicates whether
-// any of them encountered an error.
+// any of them encountered an error or failed a response handler test.
 func ExecuteScenario(ctx context.Context, s internal.Scenario) internal.Execution {
-	requests, err := Evaluate(s.Collection)
+	return executeScenario(ctx, s, newOutputWriter(s.OutputPolicy), 0, 0)
+}
+
+// executeScenario runs the given execution of a worker.
+func executeScenario(ctx context.Context, s internal.Scenario, out *outputWriter, worker, iteration int) internal.Execution {
+	src := newSource(s.Seed, worker, iteration)
+	vars, err := s.Collection.EvaluateVariablesWith(src)
+	var scoped []internal.ScopedVariable
+	if err == nil {
+		scoped, err = s.Collection.EvaluateScopedVariables(vars, src)
+	}
 	if err != nil {
 		return internal.Execution{
 			Responses: nil,
@@ -85,54 +117,246 @@ func ExecuteScenario(ctx context.Context, s internal.Scenario) internal.Executio
 		}
 	}
 
-	responses := make([]internal.Response, 0, len(requests))
+	jar, _ := cookiejar.New(nil)
+	globals := make(map[string]string)
+	responses := make([]internal.Response, 0, len(s.Collection.Requests))
 	anyError := false
-	for index, request := range requests {
-		response := ExecuteRequest(ctx, request)
-		response.Index = index
-		responses = append(responses, response)
-		if response.Error != nil {
-			anyError = true
+	sent := 0
+	for index, template := range s.Collection.Requests {
+		for i := 0; i < max(template.Weight, 1); i++ {
+			var response internal.Response
+			var body []byte
+			scope, err := requestVariables(vars, scoped, index, globals, template.Variables)
+			request := template
+			if err == nil {
+				request, err = evaluateRequest(template, scope, src)
+			}
+			if err == nil {
+				err = resolveUrl(&request, s.BaseURL, scope)
+			}
+			if err != nil {
+				response = internal.Response{Name: request.Name, Error: err}
+			} else {
+				response, body = executeRequest(ctx, request, scope, newBodySource(s.Seed, worker, iteration, sent), globals, jar)
+			}
+			sent++
+			response.Index = index
+			if request.ResponseOutput != nil && !request.Options.NoLog && out.shouldWrite(index, response) {
+				if err := out.write(*request.ResponseOutput, body); err != nil && response.Error == nil {
+					response.Error = fmt.Errorf("failed to write response output: %w", err)
+				}
+			}
+			responses = append(responses, response)
+			if response.Error != nil || response.AnyTestFailed() {
+				anyError = true
+			}
+			pause(ctx, thinkTime(template, s))
 		}
 	}
 
 	return internal.Execution{Responses: responses, AnyError: anyError}
 }
 
+// requestVariables merges the variables of the request at index. References within the
+// globals are resolved against all other variables, references within the overrides of
+// the request against the variables they override.
+func requestVariables(vars map[string]string, scoped []internal.ScopedVariable, index int, globals, overrides map[string]string) (map[string]string, error) {
+	scope, err := internal.ResolveVariables(overlay(overlay(vars, internal.ScopeAt(scoped, index)), globals))
+	if err != nil || len(overrides) == 0 {
+		return scope, err
+	}
+	resolved := make(map[string]string, len(overrides))
+	for k, v := range overrides {
+		resolved[k] = internal.SubstituteReferences(v, scope)
+	}
+	return overlay(scope, resolved), nil
+}
+
+// newSource returns the random source of the given execution of a worker.
+func newSource(seed uint64, worker, iteration int) *random.Source {
+	return random.New(seed, uint64(worker)<<32|uint64(iteration))
+}
+
+// newBodySource returns the random sources of the body of the n-th request sent by the
+// given execution of a worker. Bodies do not share the source of their execution, as the
+// transport may still stream a body while the execution evaluates the next request.
+func newBodySource(seed uint64, worker, iteration, n int) sourceFunc {
+	h := fnv.New64a()
+	_ = binary.Write(h, binary.LittleEndian, [3]uint64{uint64(worker), uint64(iteration), uint64(n)})
+	stream := h.Sum64()
+	return func() *random.Source {
+		return random.New(seed, stream)
+	}
+}
+
+// thinkTime returns the pause after the given request, which is the request's
+// own think time if set and the scenario's think time otherwise.
+func thinkTime(r internal.Request, s internal.Scenario) time.Duration {
+	if r.ThinkTime > 0 {
+		return r.ThinkTime
+	}
+	return s.ThinkTime
+}
+
+// pause waits for the given duration or until ctx is done.
+func pause(ctx context.Context, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
+
 // ExecuteRequest performs a single HTTP request described by the given internal.Request.
 //
 // The request uses the provided context `ctx`, which may include cancellation or a timeout.
-// If `ctx` has no deadline, a default timeout (e.g., 5 seconds) is applied to prevent
-// the request from hanging indefinitely.
+// A timeout given by the request options is always applied. Otherwise, if `ctx` has no
+// deadline, a default timeout (e.g., 5 seconds) is applied to prevent the request from
+// hanging indefinitely.
 //
 // The returned internal.Response contains the HTTP status code, the elapsed duration of
-// the request, and any error encountered during creation or execution.
+// the request, the results of the response handler tests, and any error encountered
+// during creation or execution.
 func ExecuteRequest(ctx context.Context, r internal.Request) internal.Response {
-	ctx, cancel := withDefaultTimeout(ctx, 5*time.Second)
+	response, _ := executeRequest(ctx, r, nil, nil, make(map[string]string), nil)
+	return response
+}
+
+// executeRequest performs the request like ExecuteRequest. The response body is only
+// read and returned if it is needed by the response handler, the response output or to
+// count the errors of a GraphQL response. The body of an SSE request is read as event
+// stream, whose duration replaces the request timeout if given. Dynamic variables in file
+// bodies take their random values from bodySource.
+func executeRequest(ctx context.Context, r internal.Request, vars map[string]string, bodySource sourceFunc, globals map[string]string, jar http.CookieJar) (internal.Response, []byte) {
+	var cancel context.CancelFunc
+	if r.SSE != nil && r.SSE.Duration > 0 {
+		ctx, cancel = context.WithTimeoutCause(ctx, r.SSE.Duration, errStreamDone)
+	} else {
+		ctx, cancel = withTimeout(ctx, r.Options.Timeout)
+	}
 	defer cancel()
 
+	if r.WebSocket != nil {
+		return executeWebSocket(ctx, r, jar)
+	}
+	if r.GRPC != nil {
+		return executeGRPC(ctx, r)
+	}
+
 	result := internal.Response{Error: nil, Name: r.Name}
-	req, err := http.NewRequestWithContext(ctx, r.Method, r.Url, bytes.NewBuffer([]byte(r.Body)))
+	client, err := clientFor(r, jar)
+	if err != nil {
+		result.Error = err
+		return result, nil
+	}
+
+	req, err := newHttpRequest(ctx, r, vars, bodySource)
 	if err != nil {
 		result.Error = err
-		return result
+		return result, nil
 	}
 
+	if r.SSE != nil {
+		req.Header.Set("Accept", "text/event-stream")
+	}
 	for key, value := range r.Headers {
 		req.Header.Set(key, value)
 	}
 
 	start := time.Now()
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
 		result.Error = err
-		return result
+		return result, nil
 	}
+	defer resp.Body.Close()
 	elapsed := time.Since(start)
 
 	result.Duration = elapsed
 	result.Status = resp.StatusCode
-	return result
+
+	if r.HttpVersion == internal.HTTP2 && resp.ProtoMajor != 2 {
+		result.Error = fmt.Errorf("expected HTTP/2 but server responded with %s", resp.Proto)
+	}
+
+	var body []byte
+	var events []script.Event
+	if r.SSE != nil {
+		stream := newEventStream(*r.SSE, start, r.ResponseHandler != "" || r.ResponseOutput != nil)
+		err := stream.read(ctx, resp.Body)
+		result.Duration = time.Since(start)
+		result.SSE = &stream.stats
+		body, events = stream.raw.Bytes(), stream.events
+		if err != nil {
+			result.Error = err
+			return result, body
+		}
+		if mimeType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mimeType != "text/event-stream" && resp.StatusCode < 400 {
+			result.Error = fmt.Errorf("expected text/event-stream but server responded with '%s'", mimeType)
+		}
+	} else {
+		if r.ResponseHandler == "" && r.ResponseOutput == nil && r.GraphQL == nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			return result, nil
+		}
+
+		body, err = io.ReadAll(resp.Body)
+		if err != nil {
+			result.Error = err
+			return result, nil
+		}
+	}
+
+	if r.GraphQL != nil {
+		result.GraphQLErrors = graphQLErrors(body)
+	}
+
+	if r.ResponseHandler != "" {
+		if r.SSE != nil {
+			// The stream may have ended with its context, the handler gets its own timeout.
+			var cancel context.CancelFunc
+			ctx, cancel = withTimeout(context.WithoutCancel(ctx), r.Options.Timeout)
+			defer cancel()
+		}
+		tests, err := script.Run(ctx, r.ResponseHandler, script.Response{
+			Status:  resp.StatusCode,
+			Headers: resp.Header,
+			Body:    body,
+			Events:  events,
+		}, globals)
+		result.Tests = tests
+		if err != nil && result.Error == nil {
+			result.Error = err
+		}
+	}
+	return result, body
+}
+
+// overlay returns a copy of vars overlaid with the given variables.
+func overlay(vars, overrides map[string]string) map[string]string {
+	if len(overrides) == 0 {
+		return vars
+	}
+	merged := make(map[string]string, len(vars)+len(overrides))
+	for k, v := range vars {
+		merged[k] = v
+	}
+	for k, v := range overrides {
+		merged[k] = v
+	}
+	return merged
+}
+
+// withTimeout applies the given request timeout, or the default timeout if none is set.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return withDefaultTimeout(ctx, 5*time.Second)
 }
 
 // withDefaultTimeout returns a context with the given timeout
@@ -4,8 +4,13 @@ import (
 	"context"
 	"github.com/fdrolshagen/jetter/internal"
 	"github.com/stretchr/testify/assert"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -98,3 +103,578 @@ func TestExecuteRequest_RealRequest(t *testing.T) {
 	assert.Equal(t, 202, resp.Status)
 	assert.GreaterOrEqual(t, int(resp.Duration), 0)
 }
+
+func TestExecuteScenario_ResponseHandlerGlobalsAreUsedBySubsequentRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"token":"secret"}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(401)
+			return
+		}
+		w.WriteHeader(200)
+	}))
+	defer server.Close()
+
+	s := internal.Scenario{
+		Collection: &internal.Collection{
+			Requests: []internal.Request{
+				{
+					Method:          "POST",
+					Url:             server.URL + "/login",
+					ResponseHandler: `client.global.set("token", response.body.token);`,
+				},
+				{
+					Method:          "GET",
+					Url:             server.URL + "/users",
+					Headers:         map[string]string{"Authorization": "Bearer {{token}}"},
+					ResponseHandler: `client.test("authorized", function() { client.assert(response.status === 200, "status " + response.status); });`,
+				},
+			},
+		},
+	}
+	exec := ExecuteScenario(context.Background(), s)
+	assert.False(t, exec.AnyError)
+	assert.Len(t, exec.Responses, 2)
+	assert.Equal(t, 200, exec.Responses[1].Status)
+	assert.Len(t, exec.Responses[1].Tests, 1)
+	assert.True(t, exec.Responses[1].Tests[0].Passed)
+}
+
+func TestExecuteScenario_ScopedVariablesApplyFromTheirDeclaration(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.URL.Path == "/login" {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"token":"global"}`))
+		}
+	}))
+	defer server.Close()
+
+	request := internal.Request{Method: "GET", Url: server.URL + "/{{ID}}/{{token}}"}
+	s := internal.Scenario{
+		Collection: &internal.Collection{
+			Requests: []internal.Request{
+				request,
+				request,
+				{Method: "POST", Url: server.URL + "/login", ResponseHandler: `client.global.set("token", response.body.token);`},
+				request,
+				{Method: "GET", Url: request.Url, Variables: map[string]string{"ID": "override"}},
+			},
+			Variables: map[string]string{"ID": "file", "token": "none"},
+			ScopedVariables: []internal.ScopedVariable{
+				{Name: "ID", Value: "scoped", Request: 1},
+				{Name: "token", Value: "scoped", Request: 1},
+				{Name: "ID", Value: "shadowed", Request: 3},
+			},
+		},
+	}
+
+	exec := ExecuteScenario(context.Background(), s)
+
+	assert.False(t, exec.AnyError)
+	assert.Equal(t, []string{"/file/none", "/scoped/scoped", "/login", "/shadowed/global", "/override/global"}, paths)
+}
+
+func TestExecuteScenario_VariablesAreStablePerExecution(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+	}))
+	defer server.Close()
+
+	s := internal.Scenario{Collection: &internal.Collection{
+		Requests:  []internal.Request{{Method: "GET", Url: server.URL + "/{{ID}}/{{$randomInt}}{{$uuid}}", Weight: 2}},
+		Variables: map[string]string{"ID": "{{$uuid}}"},
+	}}
+
+	ExecuteScenario(context.Background(), s)
+	s.Seed = 1
+	ExecuteScenario(context.Background(), s)
+
+	assert.Len(t, paths, 4)
+	ids, inline := map[string]bool{}, map[string]bool{}
+	for _, path := range paths {
+		segments := strings.Split(path, "/")
+		ids[segments[1]], inline[segments[2]] = true, true
+	}
+	assert.Len(t, ids, 2, "one ID per execution")
+	assert.Len(t, inline, 4, "a new inline value per request")
+	assert.Equal(t, strings.Split(paths[0], "/")[1], strings.Split(paths[1], "/")[1])
+}
+
+func TestExecuteScenario_SameSeedSendsSameData(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, r.URL.Path+" "+r.Header.Get("X-A")+r.Header.Get("X-B")+" "+string(body))
+	}))
+	defer server.Close()
+
+	bodyFile := filepath.Join(t.TempDir(), "body.json")
+	assert.NoError(t, os.WriteFile(bodyFile, []byte(`{"id": "{{$uuid}}", "user": "{{USER}}"}`), 0o644))
+
+	collection := &internal.Collection{
+		Requests: []internal.Request{
+			{
+				Method:  "POST",
+				Url:     server.URL + "/{{ID}}/{{$random.alphanumeric(8)}}",
+				Headers: map[string]string{"X-A": "{{$randomInt}}", "X-B": "{{$random.float}}"},
+				Body:    "{{$random.email}} {{SCOPED}}",
+			},
+			{Method: "POST", Url: server.URL + "/file", BodyFile: &internal.BodyFile{Path: bodyFile}},
+		},
+		Variables:       map[string]string{"ID": "{{$uuid}}", "USER": "{{$random.alphabetic(6)}}", "HEX": "{{$random.hexadecimal(5)}}"},
+		ScopedVariables: []internal.ScopedVariable{{Name: "SCOPED", Value: "{{$random.integer(1, 1000000)}}", Request: 0}},
+	}
+	run := func(seed uint64, worker, iteration int) []string {
+		requests = nil
+		s := internal.Scenario{Collection: collection, Seed: seed}
+		exec := executeScenario(context.Background(), s, newOutputWriter(internal.OutputFirst), worker, iteration)
+		assert.False(t, exec.AnyError)
+		return requests
+	}
+
+	first := run(42, 1, 7)
+	assert.Len(t, first, 2)
+	assert.Equal(t, first, run(42, 1, 7))
+	assert.NotEqual(t, first, run(43, 1, 7))
+	assert.NotEqual(t, first, run(42, 2, 7))
+	assert.NotEqual(t, first, run(42, 1, 8))
+}
+
+func TestSubmit_ReportsSeed(t *testing.T) {
+	s := internal.Scenario{Collection: &internal.Collection{}, Seed: 42}
+
+	assert.Equal(t, uint64(42), Submit(s).Seed)
+	s.Duration = 50 * time.Millisecond
+	assert.Equal(t, uint64(42), Submit(s).Seed)
+}
+
+func TestExecuteScenario_ResolvesReferencesToGlobals(t *testing.T) {
+	var auth []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = append(auth, r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"token":"secret"}`))
+	}))
+	defer server.Close()
+
+	request := internal.Request{Method: "GET", Url: "{{api}}/users", Headers: map[string]string{"Authorization": "{{auth}}"}}
+	s := internal.Scenario{
+		Collection: &internal.Collection{
+			Requests: []internal.Request{
+				{Method: "POST", Url: "{{api}}/login", ResponseHandler: `client.global.set("token", response.body.token);`},
+				request,
+			},
+			Variables: map[string]string{"api": "{{URL}}/v2", "URL": server.URL, "auth": "Bearer {{token}}"},
+		},
+	}
+
+	exec := ExecuteScenario(context.Background(), s)
+
+	assert.False(t, exec.AnyError)
+	assert.Equal(t, []string{"", "Bearer secret"}, auth)
+}
+
+func TestExecuteScenario_OverridesReferToTheVariablesTheyOverride(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+	}))
+	defer server.Close()
+
+	s := internal.Scenario{Collection: &internal.Collection{
+		Requests:  []internal.Request{{Method: "GET", Url: server.URL + "/{{path}}/{{id}}", Variables: map[string]string{"path": "{{path}}/{{id}}", "id": "2"}}},
+		Variables: map[string]string{"path": "users", "id": "1"},
+	}}
+
+	exec := ExecuteScenario(context.Background(), s)
+
+	assert.False(t, exec.AnyError)
+	assert.Equal(t, []string{"/users/1/2"}, paths)
+}
+
+func TestExecuteScenario_ErrorOnVariableCycleOfGlobals(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	s := internal.Scenario{Collection: &internal.Collection{Requests: []internal.Request{
+		{Name: "Set", Method: "GET", Url: server.URL, ResponseHandler: `client.global.set("a", "{{b}}"); client.global.set("b", "{{a}}");`},
+		{Name: "Use", Method: "GET", Url: server.URL + "/{{a}}"},
+	}}}
+
+	exec := ExecuteScenario(context.Background(), s)
+
+	assert.True(t, exec.AnyError)
+	assert.EqualError(t, exec.Responses[1].Error, "variable cycle: a -> b -> a")
+}
+
+func TestExecuteScenario_ResolvesRelativeUrls(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.RequestURI())
+	}))
+	defer server.Close()
+
+	collection := &internal.Collection{
+		Requests:  []internal.Request{{Method: "GET", Url: "/users?page={{page}}"}},
+		Variables: map[string]string{"page": "2", "prefix": "/v1", "baseUrl": server.URL + "/variable"},
+	}
+
+	tests := []struct {
+		name    string
+		baseURL string
+		path    string
+	}{
+		{"scenario base URL", server.URL + "{{prefix}}/", "/v1/users?page=2"},
+		{"baseUrl variable", "", "/variable/users?page=2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paths = nil
+			exec := ExecuteScenario(context.Background(), internal.Scenario{Collection: collection, BaseURL: tt.baseURL})
+
+			assert.False(t, exec.AnyError)
+			assert.Equal(t, []string{tt.path}, paths)
+		})
+	}
+}
+
+func TestExecuteScenario_ErrorOnRelativeUrlWithoutBaseUrl(t *testing.T) {
+	s := internal.Scenario{Collection: &internal.Collection{Requests: []internal.Request{{Name: "Users", Method: "GET", Url: "/users"}}}}
+
+	exec := ExecuteScenario(context.Background(), s)
+
+	assert.True(t, exec.AnyError)
+	assert.Equal(t, "Users", exec.Responses[0].Name)
+	assert.ErrorContains(t, exec.Responses[0].Error, "relative URL '/users' needs a base URL")
+}
+
+func TestResolveUrl_MapsSchemeForWebSockets(t *testing.T) {
+	r := internal.Request{Url: "/chat", WebSocket: &internal.WebSocket{}}
+
+	assert.NoError(t, resolveUrl(&r, "https://example.com", nil))
+	assert.Equal(t, "wss://example.com/chat", r.Url)
+}
+
+func TestExecuteScenario_FailedResponseHandlerTestMarksError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+	defer server.Close()
+
+	s := internal.Scenario{
+		Collection: &internal.Collection{
+			Requests: []internal.Request{{
+				Method:          "GET",
+				Url:             server.URL,
+				ResponseHandler: `client.test("created", function() { client.assert(response.status === 201, "not created"); });`,
+			}},
+		},
+	}
+	exec := ExecuteScenario(context.Background(), s)
+	assert.True(t, exec.AnyError)
+	assert.Nil(t, exec.Responses[0].Error)
+	assert.False(t, exec.Responses[0].Tests[0].Passed)
+}
+
+func TestExecuteScenario_StreamsFileBody(t *testing.T) {
+	var bodies []string
+	var lengths []int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		lengths = append(lengths, r.ContentLength)
+		w.WriteHeader(200)
+	}))
+	defer server.Close()
+
+	file := filepath.Join(t.TempDir(), "user.json")
+	err := os.WriteFile(file, []byte(`{"id": "{{ID}}"}`), 0644)
+	assert.NoError(t, err)
+
+	s := internal.Scenario{
+		Collection: &internal.Collection{
+			Variables: map[string]string{"ID": "123"},
+			Requests: []internal.Request{
+				{Method: "POST", Url: server.URL, BodyFile: &internal.BodyFile{Path: file}},
+				{Method: "POST", Url: server.URL, BodyFile: &internal.BodyFile{Path: file, Raw: true}},
+			},
+		},
+	}
+	exec := ExecuteScenario(context.Background(), s)
+	assert.False(t, exec.AnyError)
+	assert.Equal(t, []string{`{"id": "123"}`, `{"id": "{{ID}}"}`}, bodies)
+	assert.Equal(t, int64(16), lengths[1])
+}
+
+func TestExecuteScenario_BodyStreamedAfterEarlyResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	file := filepath.Join(t.TempDir(), "body.json")
+	err := os.WriteFile(file, []byte(strings.Repeat(`{"id": "{{$uuid}}"}`+"\n", 50000)), 0644)
+	assert.NoError(t, err)
+
+	request := internal.Request{Method: "POST", Url: server.URL, Headers: map[string]string{"X-Id": "{{$uuid}}"}, BodyFile: &internal.BodyFile{Path: file}}
+	s := internal.Scenario{Collection: &internal.Collection{Requests: []internal.Request{request, request, request}}, Seed: 1}
+
+	exec := ExecuteScenario(context.Background(), s)
+
+	assert.Len(t, exec.Responses, 3)
+}
+
+func TestExecuteRequest_ErrorOnMissingBodyFile(t *testing.T) {
+	req := internal.Request{Method: "POST", Url: "http://localhost", BodyFile: &internal.BodyFile{Path: "does-not-exist.json"}}
+	resp := ExecuteRequest(context.Background(), req)
+	assert.NotNil(t, resp.Error)
+}
+
+func TestSubmit_WritesFirstResponseOnly(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("pong"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	s := internal.Scenario{
+		Duration:    30 * time.Millisecond,
+		Concurrency: 2,
+		Collection: &internal.Collection{
+			Requests: []internal.Request{{
+				Method:         "GET",
+				Url:            server.URL,
+				ResponseOutput: &internal.ResponseOutput{Path: filepath.Join(dir, "ping.txt")},
+			}},
+		},
+	}
+	result := Submit(s)
+	assert.GreaterOrEqual(t, len(result.Executions), 2)
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	got, err := os.ReadFile(filepath.Join(dir, "ping.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "pong", string(got))
+}
+
+func TestExecuteRequest_HttpVersion(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Proto", r.Proto)
+		w.WriteHeader(200)
+	}))
+	server.Config.Protocols = new(http.Protocols)
+	server.Config.Protocols.SetHTTP1(true)
+	server.Config.Protocols.SetUnencryptedHTTP2(true)
+	server.Start()
+	defer server.Close()
+
+	for version, proto := range map[string]string{
+		internal.HTTP2:  "HTTP/2.0",
+		internal.HTTP11: "HTTP/1.1",
+		"":              "HTTP/1.1",
+	} {
+		req := internal.Request{
+			Method:          "GET",
+			Url:             server.URL,
+			HttpVersion:     version,
+			ResponseHandler: `client.test("proto", function() { client.assert(response.headers.valueOf("X-Proto") === "` + proto + `"); });`,
+		}
+		resp := ExecuteRequest(context.Background(), req)
+		assert.Nil(t, resp.Error, version)
+		assert.True(t, resp.Tests[0].Passed, version)
+	}
+}
+
+func TestExecuteRequest_SendsMethodUnchanged(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Method", r.Method)
+		w.Header().Set("X-Body", string(body))
+		w.WriteHeader(200)
+	}))
+	defer server.Close()
+
+	for _, method := range []string{"PROPFIND", "MKCOL", "QUERY", "PURGE", "TRACE"} {
+		req := internal.Request{
+			Method: method,
+			Url:    server.URL,
+			Body:   "payload",
+			ResponseHandler: `client.test("method", function() {
+				client.assert(response.headers.valueOf("X-Method") === "` + method + `");
+				client.assert(response.headers.valueOf("X-Body") === "payload");
+			});`,
+		}
+		resp := ExecuteRequest(context.Background(), req)
+		assert.Nil(t, resp.Error, method)
+		assert.True(t, resp.Tests[0].Passed, method)
+	}
+}
+
+func TestExecuteRequest_ErrorWhenHttp2IsNotSupported(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+	defer server.Close()
+
+	resp := ExecuteRequest(context.Background(), internal.Request{Method: "GET", Url: server.URL, HttpVersion: internal.HTTP2})
+	assert.NotNil(t, resp.Error)
+}
+
+func TestSubmit_MultipartUploadUnderConcurrency(t *testing.T) {
+	var mu sync.Mutex
+	var names []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			w.WriteHeader(400)
+			return
+		}
+		mu.Lock()
+		names = append(names, r.MultipartForm.Value["name"][0])
+		mu.Unlock()
+		w.WriteHeader(201)
+	}))
+	defer server.Close()
+
+	file := filepath.Join(t.TempDir(), "data.bin")
+	assert.NoError(t, os.WriteFile(file, []byte("payload"), 0644))
+
+	s := internal.Scenario{
+		Duration:    30 * time.Millisecond,
+		Concurrency: 3,
+		Collection: &internal.Collection{
+			Variables: map[string]string{"NAME": "foo"},
+			Requests: []internal.Request{{
+				Method:  "POST",
+				Url:     server.URL,
+				Headers: map[string]string{"Content-Type": "multipart/form-data; boundary=abc"},
+				Multipart: &internal.MultipartBody{
+					Boundary: "abc",
+					Parts: []internal.MultipartPart{
+						{Headers: map[string]string{"Content-Disposition": `form-data; name="name"`}, Content: "{{NAME}}"},
+						{Headers: map[string]string{"Content-Disposition": `form-data; name="file"; filename="data.bin"`}, File: &internal.BodyFile{Path: file, Raw: true}},
+					},
+				},
+			}},
+		},
+	}
+	result := Submit(s)
+	assert.GreaterOrEqual(t, len(result.Executions), 3)
+	for _, exec := range result.Executions {
+		for _, resp := range exec.Responses {
+			if resp.Error == nil {
+				assert.Equal(t, 201, resp.Status)
+			}
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	assert.NotEmpty(t, names)
+	for _, name := range names {
+		assert.Equal(t, "foo", name)
+	}
+}
+
+func TestExecuteRequest_Options(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirect":
+			http.Redirect(w, r, "/target", http.StatusFound)
+		case "/slow":
+			time.Sleep(100 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	t.Run("follows redirects by default", func(t *testing.T) {
+		resp := ExecuteRequest(context.Background(), internal.Request{Method: "GET", Url: server.URL + "/redirect"})
+		assert.Equal(t, 200, resp.Status)
+	})
+
+	t.Run("no redirect", func(t *testing.T) {
+		resp := ExecuteRequest(context.Background(), internal.Request{
+			Method:  "GET",
+			Url:     server.URL + "/redirect",
+			Options: internal.RequestOptions{NoRedirect: true},
+		})
+		assert.Nil(t, resp.Error)
+		assert.Equal(t, 302, resp.Status)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		resp := ExecuteRequest(context.Background(), internal.Request{
+			Method:  "GET",
+			Url:     server.URL + "/slow",
+			Options: internal.RequestOptions{Timeout: 10 * time.Millisecond},
+		})
+		assert.NotNil(t, resp.Error)
+	})
+}
+
+func TestExecuteScenario_CookieJar(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc"})
+			return
+		}
+		if _, err := r.Cookie("session"); err != nil {
+			w.WriteHeader(401)
+		}
+	}))
+	defer server.Close()
+
+	s := internal.Scenario{
+		Collection: &internal.Collection{
+			Requests: []internal.Request{
+				{Method: "POST", Url: server.URL + "/login"},
+				{Method: "GET", Url: server.URL + "/users"},
+				{Method: "GET", Url: server.URL + "/users", Options: internal.RequestOptions{NoCookieJar: true}},
+			},
+		},
+	}
+	exec := ExecuteScenario(context.Background(), s)
+	assert.Equal(t, 200, exec.Responses[1].Status)
+	assert.Equal(t, 401, exec.Responses[2].Status)
+}
+
+func TestExecuteScenario_WeightAndThinkTime(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+	defer server.Close()
+
+	s := internal.Scenario{
+		ThinkTime: 5 * time.Millisecond,
+		Collection: &internal.Collection{
+			Requests: []internal.Request{
+				{Name: "login", Method: "POST", Url: server.URL},
+				{Name: "browse", Method: "GET", Url: server.URL, Weight: 3, ThinkTime: 20 * time.Millisecond},
+			},
+		},
+	}
+
+	start := time.Now()
+	exec := ExecuteScenario(context.Background(), s)
+	elapsed := time.Since(start)
+
+	assert.False(t, exec.AnyError)
+	assert.Len(t, exec.Responses, 4)
+	assert.Equal(t, 0, exec.Responses[0].Index)
+	for _, resp := range exec.Responses[1:] {
+		assert.Equal(t, 1, resp.Index)
+		assert.Equal(t, "browse", resp.Name)
+	}
+	assert.GreaterOrEqual(t, elapsed, 65*time.Millisecond)
+}
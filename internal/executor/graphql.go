@@ -0,0 +1,53 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/fdrolshagen/jetter/internal"
+	"net/http"
+	"strings"
+)
+
+// graphQLPayload is the JSON body of a GraphQL-over-HTTP POST request.
+type graphQLPayload struct {
+	Query         string          `json:"query"`
+	OperationName string          `json:"operationName,omitempty"`
+	Variables     json.RawMessage `json:"variables,omitempty"`
+}
+
+// newGraphQLRequest creates the POST request of a GraphQL operation. The Content-Type and
+// Accept headers of GraphQL over HTTP are set, unless the request defines its own.
+func newGraphQLRequest(ctx context.Context, r internal.Request) (*http.Request, error) {
+	payload := graphQLPayload{Query: r.GraphQL.Query, OperationName: r.GraphQL.OperationName}
+	if strings.TrimSpace(r.GraphQL.Variables) != "" {
+		if err := json.Unmarshal([]byte(r.GraphQL.Variables), &payload.Variables); err != nil {
+			return nil, fmt.Errorf("invalid GraphQL variables: %w", err)
+		}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, r.Method, r.Url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/graphql-response+json, application/json")
+	return req, nil
+}
+
+// graphQLErrors returns the number of entries of the `errors` array of a GraphQL response.
+// Bodies that are no JSON object have no errors.
+func graphQLErrors(body []byte) int {
+	var resp struct {
+		Errors []json.RawMessage `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return 0
+	}
+	return len(resp.Errors)
+}
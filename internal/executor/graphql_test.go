@@ -0,0 +1,68 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/fdrolshagen/jetter/internal"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestExecuteRequest_GraphQL(t *testing.T) {
+	var received map[string]any
+	var contentType, accept string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType, accept = r.Header.Get("Content-Type"), r.Header.Get("Accept")
+		received = nil
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		if received["operationName"] == "Broken" {
+			_, _ = w.Write([]byte(`{"data": null, "errors": [{"message": "boom"}, {"message": "bang"}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data": {"user": {"name": "jetter"}}, "errors": []}`))
+	}))
+	defer server.Close()
+
+	req := internal.Request{
+		Method: "POST",
+		Url:    server.URL,
+		GraphQL: &internal.GraphQL{
+			Query:         "query GetUser($id: ID!) { user(id: $id) { name } }",
+			OperationName: "GetUser",
+			Variables:     `{"id": "42"}`,
+		},
+	}
+	resp := ExecuteRequest(context.Background(), req)
+	assert.Nil(t, resp.Error)
+	assert.Equal(t, 0, resp.GraphQLErrors)
+	assert.False(t, resp.Failed())
+	assert.Equal(t, "application/json", contentType)
+	assert.Equal(t, "application/graphql-response+json, application/json", accept)
+	assert.Equal(t, map[string]any{
+		"query":         "query GetUser($id: ID!) { user(id: $id) { name } }",
+		"operationName": "GetUser",
+		"variables":     map[string]any{"id": "42"},
+	}, received)
+
+	req.GraphQL = &internal.GraphQL{Query: "query Broken { fail }", OperationName: "Broken"}
+	req.Headers = map[string]string{"Content-Type": "application/json; charset=utf-8"}
+	resp = ExecuteRequest(context.Background(), req)
+	assert.Nil(t, resp.Error)
+	assert.Equal(t, 200, resp.Status)
+	assert.Equal(t, 2, resp.GraphQLErrors)
+	assert.True(t, resp.Failed())
+	assert.Equal(t, "application/json; charset=utf-8", contentType)
+	assert.NotContains(t, received, "variables")
+}
+
+func TestExecuteRequest_ErrorOnInvalidGraphQLVariables(t *testing.T) {
+	req := internal.Request{
+		Method:  "POST",
+		Url:     "http://localhost",
+		GraphQL: &internal.GraphQL{Query: "{ users { id } }", Variables: `{"id": }`},
+	}
+	resp := ExecuteRequest(context.Background(), req)
+	assert.ErrorContains(t, resp.Error, "invalid GraphQL variables")
+}
@@ -0,0 +1,162 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/fdrolshagen/jetter/internal"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// grpcConns holds a client connection per server, shared by all executions.
+var grpcConns sync.Map
+
+// executeGRPC calls the gRPC method of r with the JSON messages of its body. Headers are sent
+// as metadata. The response messages are returned as JSON, one per line, to be written to
+// the response output. Response handler scripts are not run for gRPC requests.
+func executeGRPC(ctx context.Context, r internal.Request) (internal.Response, []byte) {
+	result := internal.Response{Name: r.Name}
+	target, err := internal.ParseGRPCTarget(r.Url)
+	if err != nil {
+		result.Error = fmt.Errorf("invalid gRPC target '%s': %w", r.Url, err)
+		return result, nil
+	}
+
+	conn, err := grpcConn(target)
+	if err != nil {
+		result.Error = err
+		return result, nil
+	}
+	method, err := findMethod(ctx, conn, r.GRPC.Protos, target)
+	if err != nil {
+		result.Error = err
+		return result, nil
+	}
+	requests, err := grpcMessages(r.Body, method)
+	if err != nil {
+		result.Error = err
+		return result, nil
+	}
+
+	ctx = metadata.NewOutgoingContext(ctx, metadata.New(r.Headers))
+	start := time.Now()
+	responses, err := invoke(ctx, conn, target, method, requests)
+	result.Duration = time.Since(start)
+	result.GRPCStatus = status.Code(err).String()
+
+	var body bytes.Buffer
+	for _, msg := range responses {
+		data, err := protojson.Marshal(msg)
+		if err != nil {
+			result.Error = err
+			break
+		}
+		body.Write(data)
+		body.WriteString("\n")
+	}
+	return result, body.Bytes()
+}
+
+// grpcConn returns the client connection to the server of target.
+func grpcConn(target internal.GRPCTarget) (*grpc.ClientConn, error) {
+	key := fmt.Sprintf("%s|%t", target.Address, target.TLS)
+	if conn, ok := grpcConns.Load(key); ok {
+		return conn.(*grpc.ClientConn), nil
+	}
+
+	creds := insecure.NewCredentials()
+	if target.TLS {
+		creds = credentials.NewTLS(&tls.Config{})
+	}
+	conn, err := grpc.NewClient(target.Address, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, err
+	}
+	if existing, loaded := grpcConns.LoadOrStore(key, conn); loaded {
+		_ = conn.Close()
+		return existing.(*grpc.ClientConn), nil
+	}
+	return conn, nil
+}
+
+// grpcMessages converts the JSON objects of body into request messages of the method.
+// An empty body is a single empty message, unless the method is client streaming.
+func grpcMessages(body string, method protoreflect.MethodDescriptor) ([]proto.Message, error) {
+	var messages []proto.Message
+	dec := json.NewDecoder(strings.NewReader(body))
+	for {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid gRPC request message: %w", err)
+		}
+		msg := dynamicpb.NewMessage(method.Input())
+		if err := protojson.Unmarshal(raw, msg); err != nil {
+			return nil, fmt.Errorf("invalid gRPC request message for %s: %w", method.Input().FullName(), err)
+		}
+		messages = append(messages, msg)
+	}
+
+	if method.IsStreamingClient() {
+		return messages, nil
+	}
+	switch len(messages) {
+	case 0:
+		return []proto.Message{dynamicpb.NewMessage(method.Input())}, nil
+	case 1:
+		return messages, nil
+	default:
+		return nil, fmt.Errorf("gRPC method '%s' takes a single request message, got %d", method.Name(), len(messages))
+	}
+}
+
+// invoke sends the request messages and receives the response messages until the server ends
+// the call. The returned error carries the gRPC status of the call.
+func invoke(ctx context.Context, conn *grpc.ClientConn, target internal.GRPCTarget, method protoreflect.MethodDescriptor, requests []proto.Message) ([]proto.Message, error) {
+	desc := &grpc.StreamDesc{
+		StreamName:    string(method.Name()),
+		ClientStreams: method.IsStreamingClient(),
+		ServerStreams: method.IsStreamingServer(),
+	}
+	stream, err := conn.NewStream(ctx, desc, target.FullMethod())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, msg := range requests {
+		// A failed send ends the call, its status is returned by RecvMsg.
+		if err := stream.SendMsg(msg); err != nil {
+			break
+		}
+	}
+	if err := stream.CloseSend(); err != nil {
+		return nil, err
+	}
+
+	var responses []proto.Message
+	for {
+		msg := dynamicpb.NewMessage(method.Output())
+		if err := stream.RecvMsg(msg); errors.Is(err, io.EOF) {
+			return responses, nil
+		} else if err != nil {
+			return responses, err
+		}
+		responses = append(responses, msg)
+	}
+}
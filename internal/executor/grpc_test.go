@@ -0,0 +1,262 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/fdrolshagen/jetter/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const greeterProto = "testdata/greeter.proto"
+
+// startGreeter starts the Greeter service of testdata/greeter.proto with server reflection.
+// Replies greet with the `x-greeting` metadata if given, "Hello" otherwise.
+func startGreeter(t *testing.T) string {
+	files, err := loadProtos(context.Background(), []string{greeterProto})
+	require.NoError(t, err)
+	d, err := files.FindDescriptorByName("jetter.test.Greeter")
+	require.NoError(t, err)
+	service := d.(protoreflect.ServiceDescriptor)
+	input, output := service.Methods().Get(0).Input(), service.Methods().Get(0).Output()
+
+	reply := func(ctx context.Context, names ...string) proto.Message {
+		greeting := "Hello"
+		if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get("x-greeting")) > 0 {
+			greeting = md.Get("x-greeting")[0]
+		}
+		msg := dynamicpb.NewMessage(output)
+		msg.Set(output.Fields().ByName("message"), protoreflect.ValueOfString(greeting+" "+strings.Join(names, ", ")))
+		return msg
+	}
+	name := func(msg proto.Message) string {
+		return msg.ProtoReflect().Get(input.Fields().ByName("name")).String()
+	}
+
+	server := grpc.NewServer()
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: string(service.FullName()),
+		HandlerType: (*any)(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: "SayHello",
+			Handler: func(_ any, ctx context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+				in := dynamicpb.NewMessage(input)
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				if name(in) == "" {
+					return nil, status.Error(codes.InvalidArgument, "name is required")
+				}
+				return reply(ctx, name(in)), nil
+			},
+		}},
+		Streams: []grpc.StreamDesc{
+			{
+				StreamName:    "SayHellos",
+				ServerStreams: true,
+				Handler: func(_ any, stream grpc.ServerStream) error {
+					in := dynamicpb.NewMessage(input)
+					if err := stream.RecvMsg(in); err != nil {
+						return err
+					}
+					times := in.Get(input.Fields().ByName("times")).Int()
+					for i := int64(0); i < times; i++ {
+						if err := stream.SendMsg(reply(stream.Context(), name(in))); err != nil {
+							return err
+						}
+					}
+					return nil
+				},
+			},
+			{
+				StreamName:    "CollectHellos",
+				ClientStreams: true,
+				Handler: func(_ any, stream grpc.ServerStream) error {
+					var names []string
+					for {
+						in := dynamicpb.NewMessage(input)
+						if err := stream.RecvMsg(in); err == io.EOF {
+							return stream.SendMsg(reply(stream.Context(), names...))
+						} else if err != nil {
+							return err
+						}
+						names = append(names, name(in))
+					}
+				},
+			},
+			{
+				StreamName:    "Chat",
+				ClientStreams: true,
+				ServerStreams: true,
+				Handler: func(_ any, stream grpc.ServerStream) error {
+					for {
+						in := dynamicpb.NewMessage(input)
+						if err := stream.RecvMsg(in); err == io.EOF {
+							return nil
+						} else if err != nil {
+							return err
+						}
+						if err := stream.SendMsg(reply(stream.Context(), name(in))); err != nil {
+							return err
+						}
+					}
+				},
+			},
+		},
+	}, struct{}{})
+	reflectionpb.RegisterServerReflectionServer(server, reflection.NewServerV1(reflection.ServerOptions{
+		Services:           server,
+		DescriptorResolver: files,
+	}))
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+	return lis.Addr().String()
+}
+
+func grpcRequest(address, method, body string, protos ...string) internal.Request {
+	return internal.Request{
+		Name:    method,
+		Method:  "POST",
+		Url:     address + "/jetter.test.Greeter/" + method,
+		Body:    body,
+		Headers: map[string]string{},
+		GRPC:    &internal.GRPC{Protos: protos},
+	}
+}
+
+// replies returns the messages of the JSON response body.
+func replies(t *testing.T, body []byte) []string {
+	var messages []string
+	for _, line := range strings.Split(strings.TrimSpace(string(body)), "\n") {
+		var reply struct{ Message string }
+		require.NoError(t, json.Unmarshal([]byte(line), &reply))
+		messages = append(messages, reply.Message)
+	}
+	return messages
+}
+
+func TestExecuteRequest_GRPC(t *testing.T) {
+	address := startGreeter(t)
+
+	tests := []struct {
+		name    string
+		method  string
+		body    string
+		replies []string
+	}{
+		{"unary", "SayHello", `{"name": "jetter"}`, []string{"Hello jetter"}},
+		{"server streaming", "SayHellos", `{"name": "jetter", "times": 3}`, []string{"Hello jetter", "Hello jetter", "Hello jetter"}},
+		{"client streaming", "CollectHellos", "{\"name\": \"a\"}\n{\"name\": \"b\"}", []string{"Hello a, b"}},
+		{"bidirectional streaming", "Chat", "{\"name\": \"a\"}\n\n{\"name\": \"b\"}", []string{"Hello a", "Hello b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := executeRequest(context.Background(), grpcRequest(address, tt.method, tt.body), nil, nil, map[string]string{}, nil)
+
+			assert.Nil(t, resp.Error)
+			assert.Equal(t, "OK", resp.GRPCStatus)
+			assert.Equal(t, 0, resp.Status)
+			assert.False(t, resp.Failed())
+			assert.Equal(t, tt.replies, replies(t, body))
+		})
+	}
+}
+
+func TestExecuteRequest_GRPCDescriptorSources(t *testing.T) {
+	address := startGreeter(t)
+
+	files, err := loadProtos(context.Background(), []string{greeterProto})
+	require.NoError(t, err)
+	set := &descriptorpb.FileDescriptorSet{}
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		set.File = append(set.File, protodesc.ToFileDescriptorProto(fd))
+		return true
+	})
+	data, err := proto.Marshal(set)
+	require.NoError(t, err)
+	descriptorSet := filepath.Join(t.TempDir(), "greeter.protoset")
+	require.NoError(t, os.WriteFile(descriptorSet, data, 0644))
+
+	for name, protos := range map[string][]string{
+		"reflection":     nil,
+		"proto file":     {greeterProto},
+		"descriptor set": {descriptorSet},
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := grpcRequest(address, "SayHello", `{"name": "jetter"}`, protos...)
+			req.Headers["X-Greeting"] = "Hi"
+
+			resp, body := executeRequest(context.Background(), req, nil, nil, map[string]string{}, nil)
+
+			assert.Nil(t, resp.Error)
+			assert.Equal(t, "OK", resp.GRPCStatus)
+			assert.Equal(t, []string{"Hi jetter"}, replies(t, body))
+		})
+	}
+}
+
+func TestExecuteRequest_GRPCStatus(t *testing.T) {
+	address := startGreeter(t)
+
+	resp := ExecuteRequest(context.Background(), grpcRequest(address, "SayHello", ""))
+
+	assert.Nil(t, resp.Error)
+	assert.Equal(t, "InvalidArgument", resp.GRPCStatus)
+	assert.True(t, resp.Failed())
+}
+
+func TestExecuteRequest_GRPCErrors(t *testing.T) {
+	address := startGreeter(t)
+
+	tests := []struct {
+		name string
+		req  internal.Request
+		err  string
+	}{
+		{"unknown method", grpcRequest(address, "SayGoodbye", "{}"), "gRPC method 'SayGoodbye' not found in service 'jetter.test.Greeter'"},
+		{"unknown field", grpcRequest(address, "SayHello", `{"nickname": "j"}`), "invalid gRPC request message for jetter.test.HelloRequest"},
+		{"invalid JSON", grpcRequest(address, "SayHello", `{"name": `), "invalid gRPC request message"},
+		{"several messages for unary", grpcRequest(address, "SayHello", `{"name": "a"} {"name": "b"}`), "takes a single request message, got 2"},
+		{"missing proto file", grpcRequest(address, "SayHello", "{}", "testdata/missing.proto"), "failed to compile 'testdata/missing.proto'"},
+		{"invalid target", internal.Request{Url: address + "/SayHello", GRPC: &internal.GRPC{}}, "invalid gRPC target"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := ExecuteRequest(context.Background(), tt.req)
+			assert.ErrorContains(t, resp.Error, tt.err)
+			assert.Empty(t, resp.GRPCStatus)
+		})
+	}
+}
+
+func TestLoadProtos_ShouldResolveWellKnownTypes(t *testing.T) {
+	files, err := loadProtos(context.Background(), []string{greeterProto})
+	require.NoError(t, err)
+
+	d, err := files.FindDescriptorByName("jetter.test.HelloReply")
+	require.NoError(t, err)
+	field := d.(protoreflect.MessageDescriptor).Fields().ByName("sent_at")
+	assert.Equal(t, protoreflect.FullName("google.protobuf.Timestamp"), field.Message().FullName())
+}
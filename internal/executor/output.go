@@ -0,0 +1,118 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"github.com/fdrolshagen/jetter/internal"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// outputWriter writes response bodies to the ResponseOutput of their request.
+// It is shared by all workers of a run, so the OutputPolicy applies across
+// all executions of a scenario.
+type outputWriter struct {
+	policy  internal.OutputPolicy
+	mu      sync.Mutex
+	written map[int]bool
+	next    map[string]int
+}
+
+func newOutputWriter(policy internal.OutputPolicy) *outputWriter {
+	if policy == "" {
+		policy = internal.OutputFirst
+	}
+	return &outputWriter{policy: policy, written: make(map[int]bool), next: make(map[string]int)}
+}
+
+// shouldWrite reports whether the response of the request with the given index
+// is written according to the policy.
+func (w *outputWriter) shouldWrite(index int, resp internal.Response) bool {
+	if resp.Status == 0 {
+		return false
+	}
+
+	switch w.policy {
+	case internal.OutputAll:
+		return true
+	case internal.OutputFailures:
+		return resp.Failed()
+	default:
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if w.written[index] {
+			return false
+		}
+		w.written[index] = true
+		return true
+	}
+}
+
+func (w *outputWriter) write(out internal.ResponseOutput, body []byte) error {
+	if err := os.MkdirAll(filepath.Dir(out.Path), 0755); err != nil {
+		return err
+	}
+	if out.Overwrite {
+		return overwriteFile(out.Path, body)
+	}
+	return w.writeNewFile(out.Path, body)
+}
+
+// overwriteFile replaces the file at path atomically, so concurrent workers
+// never leave a partially written file behind.
+func overwriteFile(path string, body []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(body); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// writeNewFile writes to path, or to the first free path with a numeric suffix
+// (e.g. sample-1.json, sample-2.json) if the file already exists. The next suffix
+// of each path is remembered, so files are only probed when a create fails.
+func (w *outputWriter) writeNewFile(path string, body []byte) error {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+
+	for i := w.reserve(path, -1); ; i = w.reserve(path, i) {
+		candidate := path
+		if i > 0 {
+			candidate = fmt.Sprintf("%s-%d%s", base, i, ext)
+		}
+		f, err := os.OpenFile(candidate, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = f.Write(body)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		return err
+	}
+}
+
+// reserve returns the next suffix to try for path, which is after the given failed one.
+func (w *outputWriter) reserve(path string, failed int) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	i := max(w.next[path], failed+1)
+	w.next[path] = i + 1
+	return i
+}
@@ -0,0 +1,88 @@
+package executor
+
+import (
+	"errors"
+	"github.com/fdrolshagen/jetter/internal"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOutputWriter_ShouldWrite(t *testing.T) {
+	ok := internal.Response{Status: 200}
+	failed := internal.Response{Status: 500}
+	noResponse := internal.Response{Error: errors.New("connection refused")}
+
+	t.Run("first", func(t *testing.T) {
+		w := newOutputWriter("")
+		assert.False(t, w.shouldWrite(0, noResponse))
+		assert.True(t, w.shouldWrite(0, ok))
+		assert.False(t, w.shouldWrite(0, ok))
+		assert.True(t, w.shouldWrite(1, failed))
+	})
+
+	t.Run("failures", func(t *testing.T) {
+		w := newOutputWriter(internal.OutputFailures)
+		assert.False(t, w.shouldWrite(0, ok))
+		assert.True(t, w.shouldWrite(0, failed))
+		assert.True(t, w.shouldWrite(0, failed))
+	})
+
+	t.Run("all", func(t *testing.T) {
+		w := newOutputWriter(internal.OutputAll)
+		assert.True(t, w.shouldWrite(0, ok))
+		assert.True(t, w.shouldWrite(0, ok))
+		assert.False(t, w.shouldWrite(0, noResponse))
+	})
+}
+
+func TestOutputWriter_AppendsNumericSuffix(t *testing.T) {
+	dir := t.TempDir()
+	w := newOutputWriter(internal.OutputAll)
+	out := internal.ResponseOutput{Path: filepath.Join(dir, "out", "sample.json")}
+
+	assert.NoError(t, w.write(out, []byte("1")))
+	assert.NoError(t, w.write(out, []byte("2")))
+	assert.NoError(t, w.write(out, []byte("3")))
+
+	for name, want := range map[string]string{"sample.json": "1", "sample-1.json": "2", "sample-2.json": "3"} {
+		got, err := os.ReadFile(filepath.Join(dir, "out", name))
+		assert.NoError(t, err)
+		assert.Equal(t, want, string(got))
+	}
+}
+
+func TestOutputWriter_Overwrites(t *testing.T) {
+	dir := t.TempDir()
+	w := newOutputWriter(internal.OutputAll)
+	out := internal.ResponseOutput{Path: filepath.Join(dir, "sample.json"), Overwrite: true}
+
+	assert.NoError(t, w.write(out, []byte("first")))
+	assert.NoError(t, w.write(out, []byte("second")))
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	got, err := os.ReadFile(out.Path)
+	assert.NoError(t, err)
+	assert.Equal(t, "second", string(got))
+}
+
+func TestOutputWriter_SkipsExistingFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"sample.json", "sample-1.json"} {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("old"), 0644))
+	}
+	w := newOutputWriter(internal.OutputAll)
+	out := internal.ResponseOutput{Path: filepath.Join(dir, "sample.json")}
+
+	assert.NoError(t, w.write(out, []byte("1")))
+	assert.NoError(t, w.write(out, []byte("2")))
+
+	for name, want := range map[string]string{"sample.json": "old", "sample-1.json": "old", "sample-2.json": "1", "sample-3.json": "2"} {
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		assert.NoError(t, err)
+		assert.Equal(t, want, string(got))
+	}
+}
@@ -0,0 +1,107 @@
+package executor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/fdrolshagen/jetter/internal"
+	"github.com/fdrolshagen/jetter/internal/script"
+	"io"
+	"strings"
+	"time"
+)
+
+// maxEventLine is the maximum length of a line of an event stream.
+const maxEventLine = 1024 * 1024
+
+// errStreamDone is the cause of the context of an event stream once its duration has elapsed.
+var errStreamDone = errors.New("event stream duration elapsed")
+
+// eventStream reads the server-sent events of a response, as specified by the HTML
+// Living Standard. The raw stream and the events are only kept if keep is set.
+type eventStream struct {
+	limits internal.SSE
+	start  time.Time
+	keep   bool
+	stats  internal.SSEStats
+	// last is the time from the start until the last event was received.
+	last   time.Duration
+	events []script.Event
+	raw    bytes.Buffer
+}
+
+func newEventStream(limits internal.SSE, start time.Time, keep bool) *eventStream {
+	s := &eventStream{limits: limits, start: start, keep: keep}
+	if keep {
+		s.events = []script.Event{}
+	}
+	return s
+}
+
+// read reads events from body until the event limit is reached or the stream ends.
+// The end of the stream duration, signalled by errStreamDone as cause of ctx, is no error.
+func (s *eventStream) read(ctx context.Context, body io.Reader) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxEventLine)
+
+	// The ID is the last event ID, which carries over to the following events.
+	var id, eventType string
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if s.keep {
+			s.raw.WriteString(line)
+			s.raw.WriteByte('\n')
+		}
+
+		if line == "" {
+			if data != nil {
+				s.dispatch(script.Event{ID: id, Type: eventType, Data: strings.Join(data, "\n")})
+			}
+			eventType, data = "", nil
+			if s.limits.Events > 0 && s.stats.Events >= s.limits.Events {
+				return nil
+			}
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			eventType = value
+		case "data":
+			data = append(data, value)
+		case "id":
+			if !strings.Contains(value, "\x00") {
+				id = value
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil && !errors.Is(context.Cause(ctx), errStreamDone) {
+		return fmt.Errorf("event stream interrupted after %d events: %w", s.stats.Events, err)
+	}
+	return nil
+}
+
+// dispatch records an event. Events without a type are of type `message`.
+func (s *eventStream) dispatch(event script.Event) {
+	elapsed := time.Since(s.start)
+	if s.stats.Events == 0 {
+		s.stats.FirstEvent = elapsed
+	} else {
+		s.stats.Gaps = append(s.stats.Gaps, elapsed-s.last)
+	}
+	s.last = elapsed
+	s.stats.Events++
+
+	if s.keep {
+		if event.Type == "" {
+			event.Type = "message"
+		}
+		s.events = append(s.events, event)
+	}
+}
@@ -0,0 +1,154 @@
+package executor
+
+import (
+	"context"
+	"github.com/fdrolshagen/jetter/internal"
+	"github.com/fdrolshagen/jetter/internal/script"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newEventServer starts a server that sends the given chunks of an event stream, pausing
+// for gap before each of them. The stream is kept open afterwards unless closeStream is set.
+func newEventServer(t *testing.T, gap time.Duration, closeStream bool, chunks ...string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != "text/event-stream" {
+			w.WriteHeader(http.StatusNotAcceptable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		for _, chunk := range chunks {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(gap):
+			}
+			_, _ = w.Write([]byte(chunk))
+			w.(http.Flusher).Flush()
+		}
+		if !closeStream {
+			<-r.Context().Done()
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func sseRequest(url string, sse internal.SSE) internal.Request {
+	return internal.Request{Name: "Events", Method: "GET", Url: url, Headers: map[string]string{}, SSE: &sse}
+}
+
+func TestEventStream_ShouldParseEvents(t *testing.T) {
+	stream := newEventStream(internal.SSE{}, time.Now(), true)
+	body := strings.Join([]string{
+		": comment",
+		"retry: 1000",
+		"id: 1",
+		"event: order",
+		"data: {\"orderId\":",
+		"data:  \"A-1\"}",
+		"",
+		"data",
+		"",
+		"event: ignored",
+		"",
+		"id: 2",
+		"data: last",
+		"",
+		"data: incomplete",
+	}, "\r\n")
+
+	err := stream.read(context.Background(), strings.NewReader(body))
+
+	require.NoError(t, err)
+	assert.Equal(t, []script.Event{
+		{ID: "1", Type: "order", Data: "{\"orderId\":\n \"A-1\"}"},
+		{ID: "1", Type: "message", Data: ""},
+		{ID: "2", Type: "message", Data: "last"},
+	}, stream.events)
+	assert.Equal(t, 3, stream.stats.Events)
+	assert.Len(t, stream.stats.Gaps, 2)
+	assert.Equal(t, strings.ReplaceAll(body, "\r\n", "\n")+"\n", stream.raw.String())
+}
+
+func TestExecuteRequest_SSEStopsAfterEventCount(t *testing.T) {
+	server := newEventServer(t, 20*time.Millisecond, false, "data: 1\n\n", "data: 2\n\n", "data: 3\n\n")
+
+	resp := ExecuteRequest(context.Background(), sseRequest(server.URL, internal.SSE{Events: 2}))
+
+	assert.Nil(t, resp.Error)
+	assert.Equal(t, 200, resp.Status)
+	require.NotNil(t, resp.SSE)
+	assert.Equal(t, 2, resp.SSE.Events)
+	assert.GreaterOrEqual(t, resp.SSE.FirstEvent, 20*time.Millisecond)
+	require.Len(t, resp.SSE.Gaps, 1)
+	assert.GreaterOrEqual(t, resp.SSE.Gaps[0], 15*time.Millisecond)
+	assert.GreaterOrEqual(t, resp.Duration, resp.SSE.FirstEvent+resp.SSE.Gaps[0])
+}
+
+func TestExecuteRequest_SSEStopsAfterDuration(t *testing.T) {
+	server := newEventServer(t, 10*time.Millisecond, false, "data: 1\n\n", "data: 2\n\n")
+
+	resp := ExecuteRequest(context.Background(), sseRequest(server.URL, internal.SSE{Duration: 200 * time.Millisecond}))
+
+	assert.Nil(t, resp.Error)
+	assert.Equal(t, 2, resp.SSE.Events)
+	assert.GreaterOrEqual(t, resp.Duration, 200*time.Millisecond)
+}
+
+func TestExecuteRequest_SSEReadsUntilServerCloses(t *testing.T) {
+	server := newEventServer(t, 0, true, "data: 1\n\ndata: 2\n\n", "data: 3\n\n")
+
+	resp := ExecuteRequest(context.Background(), sseRequest(server.URL, internal.SSE{}))
+
+	assert.Nil(t, resp.Error)
+	assert.Equal(t, 3, resp.SSE.Events)
+}
+
+func TestExecuteRequest_SSEInterruptedByTimeout(t *testing.T) {
+	server := newEventServer(t, 0, false, "data: 1\n\n")
+	req := sseRequest(server.URL, internal.SSE{Events: 2})
+	req.Options.Timeout = 100 * time.Millisecond
+
+	resp := ExecuteRequest(context.Background(), req)
+
+	assert.ErrorContains(t, resp.Error, "event stream interrupted after 1 events")
+	assert.Equal(t, 1, resp.SSE.Events)
+}
+
+func TestExecuteRequest_SSEEventsAreAvailableToResponseHandler(t *testing.T) {
+	server := newEventServer(t, 0, false, "event: order\ndata: {\"orderId\": \"A-1\"}\n\n")
+	req := sseRequest(server.URL, internal.SSE{Duration: 100 * time.Millisecond})
+	req.ResponseHandler = `
+		client.test("one order", function() { client.assert(response.events.length === 1); });
+		client.global.set("orderId", response.events[0].data.orderId);
+	`
+	globals := map[string]string{}
+
+	resp, body := executeRequest(context.Background(), req, nil, nil, globals, nil)
+
+	assert.Nil(t, resp.Error)
+	assert.False(t, resp.Failed())
+	assert.Equal(t, "A-1", globals["orderId"])
+	assert.Equal(t, "event: order\ndata: {\"orderId\": \"A-1\"}\n\n", string(body))
+}
+
+func TestExecuteRequest_SSEExpectsEventStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"events": []}`))
+	}))
+	defer server.Close()
+
+	resp := ExecuteRequest(context.Background(), sseRequest(server.URL, internal.SSE{}))
+
+	assert.EqualError(t, resp.Error, "expected text/event-stream but server responded with 'application/json'")
+	assert.Equal(t, 0, resp.SSE.Events)
+}
@@ -0,0 +1,85 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/fdrolshagen/jetter/internal"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"time"
+)
+
+// closeTimeout limits how long closing a WebSocket connection may take.
+const closeTimeout = time.Second
+
+// executeWebSocket opens the WebSocket connection of r and executes its steps. The messages
+// received from the server are returned, one per line, to be written to the response output.
+// Reading and writing is bound to the deadline of ctx. Response handler scripts are not run
+// for WebSocket requests.
+func executeWebSocket(ctx context.Context, r internal.Request, jar http.CookieJar) (internal.Response, []byte) {
+	result := internal.Response{Name: r.Name}
+	stats := &internal.WebSocketStats{}
+
+	dialer := websocket.Dialer{Proxy: http.ProxyFromEnvironment}
+	if !r.Options.NoCookieJar {
+		dialer.Jar = jar
+	}
+	header := http.Header{}
+	for key, value := range r.Headers {
+		header.Set(key, value)
+	}
+
+	start := time.Now()
+	conn, resp, err := dialer.DialContext(ctx, r.Url, header)
+	stats.Handshake = time.Since(start)
+	result.Duration = stats.Handshake
+	if resp != nil {
+		result.Status = resp.StatusCode
+	}
+	if err != nil {
+		result.Error = fmt.Errorf("websocket handshake failed: %w", err)
+		return result, nil
+	}
+	defer conn.Close()
+	result.WebSocket = stats
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetReadDeadline(deadline)
+		_ = conn.SetWriteDeadline(deadline)
+	}
+
+	var received bytes.Buffer
+	var sentAt time.Time
+	opened := time.Now()
+	for _, step := range r.WebSocket.Steps {
+		if !step.WaitForServer {
+			if err := conn.WriteMessage(websocket.TextMessage, []byte(step.Message)); err != nil {
+				result.Error = fmt.Errorf("failed to send websocket message: %w", err)
+				break
+			}
+			stats.Sent++
+			sentAt = time.Now()
+			continue
+		}
+
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			result.Error = fmt.Errorf("failed waiting for websocket message: %w", err)
+			break
+		}
+		stats.Received++
+		if !sentAt.IsZero() {
+			stats.RoundTrips = append(stats.RoundTrips, time.Since(sentAt))
+			sentAt = time.Time{}
+		}
+		received.Write(msg)
+		received.WriteString("\n")
+	}
+	stats.Open = time.Since(opened)
+	result.Duration += stats.Open
+
+	_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+		time.Now().Add(closeTimeout))
+	return result, received.Bytes()
+}
@@ -0,0 +1,109 @@
+package executor
+
+import (
+	"context"
+	"github.com/fdrolshagen/jetter/internal"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newEchoServer starts a WebSocket server that greets each client and echoes its messages.
+func newEchoServer(t *testing.T) *httptest.Server {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_ = conn.WriteMessage(websocket.TextMessage, []byte("welcome"))
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			_ = conn.WriteMessage(websocket.TextMessage, append([]byte("echo: "), msg...))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func webSocketUrl(server *httptest.Server) string {
+	return "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+func TestExecuteRequest_WebSocket(t *testing.T) {
+	server := newEchoServer(t)
+	req := internal.Request{
+		Method:  "GET",
+		Url:     webSocketUrl(server),
+		Headers: map[string]string{"Authorization": "Bearer secret"},
+		WebSocket: &internal.WebSocket{Steps: []internal.WebSocketStep{
+			{WaitForServer: true},
+			{Message: "hello"},
+			{WaitForServer: true},
+			{Message: "bye"},
+			{WaitForServer: true},
+		}},
+	}
+
+	resp, body := executeRequest(context.Background(), req, nil, nil, map[string]string{}, nil)
+
+	assert.Nil(t, resp.Error)
+	assert.Equal(t, http.StatusSwitchingProtocols, resp.Status)
+	assert.False(t, resp.Failed())
+	assert.Equal(t, "welcome\necho: hello\necho: bye\n", string(body))
+	stats := resp.WebSocket
+	assert.NotNil(t, stats)
+	assert.Equal(t, 2, stats.Sent)
+	assert.Equal(t, 3, stats.Received)
+	assert.Len(t, stats.RoundTrips, 2)
+	assert.Greater(t, stats.Handshake, time.Duration(0))
+	assert.Equal(t, stats.Handshake+stats.Open, resp.Duration)
+	assert.Greater(t, stats.MessagesPerSecond(), 0.0)
+}
+
+func TestExecuteRequest_WebSocketHandshakeRejected(t *testing.T) {
+	server := newEchoServer(t)
+	req := internal.Request{
+		Method:    "GET",
+		Url:       webSocketUrl(server),
+		WebSocket: &internal.WebSocket{Steps: []internal.WebSocketStep{{Message: "hello"}}},
+	}
+
+	resp := ExecuteRequest(context.Background(), req)
+
+	assert.ErrorContains(t, resp.Error, "websocket handshake failed")
+	assert.Equal(t, http.StatusForbidden, resp.Status)
+	assert.Nil(t, resp.WebSocket)
+}
+
+func TestExecuteRequest_WebSocketWaitTimesOut(t *testing.T) {
+	server := newEchoServer(t)
+	req := internal.Request{
+		Method:  "GET",
+		Url:     webSocketUrl(server),
+		Headers: map[string]string{"Authorization": "Bearer secret"},
+		Options: internal.RequestOptions{Timeout: 100 * time.Millisecond},
+		WebSocket: &internal.WebSocket{Steps: []internal.WebSocketStep{
+			{WaitForServer: true},
+			{WaitForServer: true},
+		}},
+	}
+
+	resp := ExecuteRequest(context.Background(), req)
+
+	assert.ErrorContains(t, resp.Error, "failed waiting for websocket message")
+	assert.Equal(t, 1, resp.WebSocket.Received)
+	assert.Empty(t, resp.WebSocket.RoundTrips)
+}
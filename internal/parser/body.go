@@ -0,0 +1,39 @@
+package parser
+
+import (
+	"bytes"
+	"strings"
+)
+
+// maxLineLength is the maximum length of a single line, e.g. of a minified JSON body.
+const maxLineLength = 16 * 1024 * 1024
+
+// scanLines is a bufio.SplitFunc like bufio.ScanLines, but keeps the line endings,
+// so that request bodies can be captured byte by byte.
+func scanLines(data []byte, atEOF bool) (int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		return i + 1, data[:i+1], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// trimTrailingSeparator removes the blank lines that separate a body from whatever
+// follows it, as well as the line ending of its last line. Everything else,
+// including indentation and blank lines within the body, is kept as is.
+func trimTrailingSeparator(body string) string {
+	lines := strings.SplitAfter(body, "\n")
+	end := len(lines)
+	for end > 0 && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+
+	body = strings.Join(lines[:end], "")
+	body = strings.TrimSuffix(body, "\n")
+	return strings.TrimSuffix(body, "\r")
+}
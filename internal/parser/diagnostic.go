@@ -0,0 +1,122 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"github.com/fdrolshagen/jetter/internal"
+	"strings"
+)
+
+// Diagnostic codes reported by the parser.
+const (
+	codeInvalidVariable      = "invalid-variable-definition"
+	codeInvalidRequestLine   = "invalid-request-line"
+	codeNonStandardMethod    = "non-standard-method"
+	codeUnsupportedVersion   = "unsupported-http-version"
+	codeInvalidContinuation  = "invalid-url-continuation"
+	codeInvalidHeader        = "invalid-header"
+	codeMissingSeparator     = "missing-body-separator"
+	codeBodyConflict         = "body-conflict"
+	codeMissingFilePath      = "missing-file-path"
+	codeDuplicateOutput      = "duplicate-output-redirect"
+	codeHandlerFileFailed    = "handler-file-failed"
+	codeInvalidDirective     = "invalid-directive-value"
+	codeMisplacedDirective   = "misplaced-directive"
+	codeMisplacedStatement   = "misplaced-statement"
+	codeUnknownDirective     = "unknown-directive"
+	codeUnsupportedDirective = "unsupported-directive"
+	codeInvalidMultipart     = "invalid-multipart"
+	codeInvalidSeparator     = "invalid-message-separator"
+	codeInvalidImport        = "invalid-import"
+	codeImportFailed         = "import-failed"
+	codeInvalidRun           = "invalid-run"
+	codeUnknownRequest       = "unknown-request"
+	codeUnexpectedContent    = "unexpected-content"
+)
+
+// syntaxError is a problem found by one of the parsing helpers. It is turned into a
+// diagnostic positioned on the line being parsed, unless line is set.
+type syntaxError struct {
+	severity internal.Severity
+	code     string
+	message  string
+	fix      string
+	line     int
+	// column is 1-based within the trimmed line, 0 refers to its first character.
+	column int
+	// nested holds the diagnostics of an imported file.
+	nested internal.Diagnostics
+}
+
+func (e *syntaxError) Error() string {
+	return e.message
+}
+
+func newSyntaxError(code, fix, format string, args ...any) *syntaxError {
+	return &syntaxError{severity: internal.SeverityError, code: code, fix: fix, message: fmt.Sprintf(format, args...)}
+}
+
+// at positions the error at the given 1-based column of the trimmed line.
+func (e *syntaxError) at(column int) *syntaxError {
+	e.column = column
+	return e
+}
+
+// columnOf returns the 1-based column of s within line, or the column after the line if s is empty or missing.
+func columnOf(line, s string) int {
+	if i := strings.Index(line, s); s != "" && i >= 0 {
+		return i + 1
+	}
+	return len(line) + 1
+}
+
+// diagnostics collects the problems found while parsing a single file.
+type diagnostics struct {
+	file string
+	list internal.Diagnostics
+}
+
+// add records err as diagnostic for the given line. raw is the untrimmed line,
+// which is used to translate the column of the error into a column of the file.
+func (d *diagnostics) add(err error, raw string, lineCounter int) {
+	var se *syntaxError
+	if !errors.As(err, &se) {
+		se = newSyntaxError("parse-error", "", "%v", err)
+	}
+
+	diagnostic := internal.Diagnostic{
+		Severity: se.severity,
+		File:     d.file,
+		Line:     lineCounter,
+		Column:   max(se.column, 1),
+		Code:     se.code,
+		Message:  se.message,
+		Fix:      se.fix,
+	}
+	if se.line > 0 {
+		diagnostic.Line = se.line
+	} else {
+		diagnostic.Column += len(raw) - len(strings.TrimLeft(raw, " \t"))
+	}
+	d.list = append(d.list, diagnostic)
+	d.list = append(d.list, se.nested...)
+}
+
+// errors returns the collected errors along with all warnings, or nil if there are no errors.
+func (d *diagnostics) errors() error {
+	if !d.list.HasErrors() {
+		return nil
+	}
+	return d.list
+}
+
+// warnings returns the collected warnings.
+func (d *diagnostics) warnings() []internal.Diagnostic {
+	var warnings []internal.Diagnostic
+	for _, w := range d.list {
+		if w.Severity == internal.SeverityWarning {
+			warnings = append(warnings, w)
+		}
+	}
+	return warnings
+}
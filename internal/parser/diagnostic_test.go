@@ -0,0 +1,132 @@
+package parser
+
+import (
+	"errors"
+	"github.com/fdrolshagen/jetter/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func requireDiagnostics(t *testing.T, err error) internal.Diagnostics {
+	t.Helper()
+	var diagnostics internal.Diagnostics
+	require.True(t, errors.As(err, &diagnostics), "expected diagnostics, got %v", err)
+	require.NotEmpty(t, diagnostics)
+	return diagnostics
+}
+
+func TestParseHttp_ShouldReportAllDiagnostics(t *testing.T) {
+	content := strings.Join([]string{
+		"@URL http://localhost",
+		"",
+		"### Broken",
+		"GET: {{URL}}/users",
+		"  Accept application/json",
+		"",
+		"### Version",
+		"# @foo",
+		"GET {{URL}}/users HTTP/3",
+		"",
+		"### Valid",
+		"GET {{URL}}/users",
+	}, "\n")
+
+	_, err := ParseHttp(strings.NewReader(content))
+
+	diagnostics := requireDiagnostics(t, err)
+	assert.Equal(t, internal.Diagnostics{
+		{
+			Severity: internal.SeverityError,
+			Line:     1,
+			Column:   1,
+			Code:     codeInvalidVariable,
+			Message:  "invalid variable definition",
+			Fix:      "define variables as '@name = value'",
+		},
+		{
+			Severity: internal.SeverityError,
+			Line:     4,
+			Column:   1,
+			Code:     codeInvalidRequestLine,
+			Message:  "invalid request method 'GET:'",
+			Fix:      "use a method made of letters, digits and the characters !#$%&'*+-.^_`|~, e.g. GET",
+		},
+		{
+			Severity: internal.SeverityError,
+			Line:     5,
+			Column:   3,
+			Code:     codeMissingSeparator,
+			Message:  "expected blank line between headers and body",
+			Fix:      "add a blank line before the body, or separate header name and value with ':'",
+		},
+		{
+			Severity: internal.SeverityWarning,
+			Line:     8,
+			Column:   3,
+			Code:     codeUnknownDirective,
+			Message:  "unknown directive '@foo' is ignored",
+			Fix:      "remove the directive or check its spelling",
+		},
+		{
+			Severity: internal.SeverityError,
+			Line:     9,
+			Column:   19,
+			Code:     codeUnsupportedVersion,
+			Message:  "unsupported HTTP version",
+			Fix:      "use HTTP/1.1, HTTP/2 or HTTP/2 (Prior Knowledge)",
+		},
+	}, diagnostics)
+}
+
+func TestParseHttpFile_ShouldReportDiagnosticsOfImportedFiles(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"auth.http": "### Login\nPOST http://localhost/login\nContent-Type\n",
+		"main.http": "import ./auth.http\n\n###\nGET http://localhost/users HTTP/9",
+	})
+	main := filepath.Join(dir, "main.http")
+
+	_, err := ParseHttpFile(main)
+
+	diagnostics := requireDiagnostics(t, err)
+	assert.Len(t, diagnostics, 3)
+	assert.Equal(t, main, diagnostics[0].File)
+	assert.Equal(t, codeImportFailed, diagnostics[0].Code)
+	assert.Equal(t, 1, diagnostics[0].Line)
+	assert.Equal(t, 8, diagnostics[0].Column)
+	assert.Equal(t, filepath.Join(dir, "auth.http"), diagnostics[1].File)
+	assert.Equal(t, codeMissingSeparator, diagnostics[1].Code)
+	assert.Equal(t, 3, diagnostics[1].Line)
+	assert.Equal(t, main, diagnostics[2].File)
+	assert.Equal(t, codeUnsupportedVersion, diagnostics[2].Code)
+	assert.Equal(t, 4, diagnostics[2].Line)
+}
+
+func TestParseHttp_ShouldPositionMultipartDiagnosticsOnThePart(t *testing.T) {
+	content := strings.Join([]string{
+		"###",
+		"POST http://localhost/upload",
+		"Content-Type: multipart/form-data; boundary=abc",
+		"",
+		"--abc",
+		"Content-Type: text/plain",
+		"",
+		"hello",
+		"--abc--",
+	}, "\n")
+
+	_, err := ParseHttp(strings.NewReader(content))
+
+	diagnostics := requireDiagnostics(t, err)
+	assert.Len(t, diagnostics, 1)
+	assert.Equal(t, codeInvalidMultipart, diagnostics[0].Code)
+	assert.Equal(t, 5, diagnostics[0].Line)
+}
+
+func TestDiagnostic_Error(t *testing.T) {
+	d := internal.Diagnostic{Severity: internal.SeverityError, File: "users.http", Line: 3, Column: 7, Message: "invalid header"}
+
+	assert.Equal(t, "parsing error: invalid header at line 3, column 7 in users.http", d.Error())
+}
@@ -0,0 +1,298 @@
+package parser
+
+import (
+	"fmt"
+	"github.com/fdrolshagen/jetter/internal"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// unsupportedDirectives are IntelliJ request directives jetter knows about but does not apply.
+var unsupportedDirectives = map[string]bool{
+	"connection-timeout": true,
+	"no-auto-encoding":   true,
+	"use-os-credentials": true,
+}
+
+// jetterDirectiveExamples are example values of the `#@jetter` settings, used in suggested fixes.
+var jetterDirectiveExamples = map[string]string{
+	"duration":    "30s",
+	"concurrency": "10",
+	"think-time":  "200ms",
+	"weight":      "3",
+	"sse":         "30s 10",
+}
+
+// parseDirective splits a comment line like `# @timeout 10` into the directive name
+// and its value. It reports false if the comment is not a directive.
+func parseDirective(line string) (string, string, bool) {
+	comment := strings.TrimPrefix(line, "#")
+	comment = strings.TrimPrefix(comment, "//")
+	comment = strings.TrimSpace(comment)
+	if !strings.HasPrefix(comment, "@") {
+		return "", "", false
+	}
+
+	name, value, _ := strings.Cut(strings.TrimPrefix(comment, "@"), " ")
+	return name, strings.TrimSpace(value), true
+}
+
+// handleRequestDirective applies an IntelliJ directive from the comments in front of
+// the request line. Directives jetter does not support are reported as warnings.
+func handleRequestDirective(line string, request *internal.Request) error {
+	name, value, ok := parseDirective(line)
+	if !ok {
+		return nil
+	}
+
+	switch name {
+	case "name":
+		if value == "" {
+			return newSyntaxError(codeInvalidDirective, "add the name of the request, e.g. '# @name login'",
+				"missing value for directive '@name'").at(len(line) + 1)
+		}
+		request.Name = value
+	case "timeout":
+		timeout, err := parseTimeout(value)
+		if err != nil {
+			return newSyntaxError(codeInvalidDirective, "use a number of seconds or a duration, e.g. '# @timeout 10' or '# @timeout 500 ms'",
+				"invalid value for directive '@timeout': %v", err).at(columnOf(line, value))
+		}
+		request.Options.Timeout = timeout
+	case "no-redirect":
+		request.Options.NoRedirect = true
+	case "no-cookie-jar":
+		request.Options.NoCookieJar = true
+	case "no-log":
+		request.Options.NoLog = true
+	case "jetter":
+		return handleRequestJetterDirective(line, value, request)
+	default:
+		err := newSyntaxError(codeUnknownDirective, "remove the directive or check its spelling", "unknown directive '@%s' is ignored", name)
+		if unsupportedDirectives[name] {
+			err = newSyntaxError(codeUnsupportedDirective, "remove the directive", "directive not supported by jetter '@%s' is ignored", name)
+		}
+		err.severity = internal.SeverityWarning
+		return err.at(columnOf(line, "@"+name))
+	}
+	return nil
+}
+
+// parseTimeout parses IntelliJ timeout values. A plain number is interpreted
+// as seconds, the units ms, s and m may follow the number with or without space.
+func parseTimeout(value string) (time.Duration, error) {
+	value = strings.ReplaceAll(value, " ", "")
+	if value == "" {
+		return 0, fmt.Errorf("missing timeout")
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		value = strconv.Itoa(seconds) + "s"
+	}
+
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if timeout <= 0 {
+		return 0, fmt.Errorf("timeout must be > 0")
+	}
+	return timeout, nil
+}
+
+// handleFileDirective applies a `#@jetter` directive from the top of the file to the scenario config.
+// Other comments at the top of the file are ignored. Paths are resolved relative to dir.
+func handleFileDirective(line string, dir string, config *internal.ScenarioConfig) error {
+	name, value, ok := parseDirective(line)
+	if !ok || name != "jetter" {
+		return nil
+	}
+
+	setting, arg := splitJetterDirective(value)
+	switch setting {
+	case "duration":
+		d, err := parsePositiveDuration(arg)
+		if err != nil {
+			return jetterDirectiveError(line, setting, arg, err)
+		}
+		config.Duration = d
+	case "concurrency":
+		n, err := parsePositiveInt(arg)
+		if err != nil {
+			return jetterDirectiveError(line, setting, arg, err)
+		}
+		config.Concurrency = n
+	case "think-time":
+		d, err := parsePositiveDuration(arg)
+		if err != nil {
+			return jetterDirectiveError(line, setting, arg, err)
+		}
+		config.ThinkTime = d
+	case "strict":
+		if arg != "" {
+			return newSyntaxError(codeInvalidDirective, "remove the value, '#@jetter strict' takes none",
+				"unexpected value for jetter directive '%s'", setting).at(columnOf(line, arg))
+		}
+		config.Strict = true
+	case "proto":
+		if arg == "" {
+			return newSyntaxError(codeMissingFilePath, "add the path of a .proto file or descriptor set, e.g. '#@jetter proto ./service.proto'",
+				"missing path for jetter directive '%s'", setting).at(len(line) + 1)
+		}
+		if !filepath.IsAbs(arg) {
+			arg = filepath.Join(dir, arg)
+		}
+		config.Protos = append(config.Protos, arg)
+	case "base-url":
+		if err := checkBaseURL(arg); err != nil {
+			return newSyntaxError(codeInvalidDirective, "use an absolute http or https URL, e.g. '#@jetter base-url https://staging.example.com/api'",
+				"invalid value for jetter directive '%s': %v", setting, err).at(max(columnOf(line, arg), len(line)+1))
+		}
+		config.BaseURL = arg
+	case "seed":
+		seed, err := strconv.ParseUint(arg, 10, 64)
+		if err != nil {
+			return newSyntaxError(codeInvalidDirective, "use an unsigned integer, e.g. '#@jetter seed 42'",
+				"invalid value for jetter directive '%s': '%s' is not an unsigned integer", setting, arg).at(max(columnOf(line, arg), len(line)+1))
+		}
+		config.Seed = &seed
+	case "weight", "sse":
+		return newSyntaxError(codeMisplacedDirective, "move the directive in front of a request line",
+			"jetter directive '%s' is only allowed in front of a request", setting).at(columnOf(line, setting))
+	default:
+		return unknownJetterDirectiveError(line, setting)
+	}
+	return nil
+}
+
+// handleRequestJetterDirective applies a `#@jetter` directive in front of the request line.
+func handleRequestJetterDirective(line string, value string, request *internal.Request) error {
+	setting, arg := splitJetterDirective(value)
+	switch setting {
+	case "think-time":
+		d, err := parsePositiveDuration(arg)
+		if err != nil {
+			return jetterDirectiveError(line, setting, arg, err)
+		}
+		request.ThinkTime = d
+	case "weight":
+		n, err := parsePositiveInt(arg)
+		if err != nil {
+			return jetterDirectiveError(line, setting, arg, err)
+		}
+		request.Weight = n
+	case "sse":
+		sse, err := parseSSE(line, arg)
+		if err != nil {
+			return err
+		}
+		request.SSE = sse
+	case "duration", "concurrency", "strict", "proto", "base-url", "seed":
+		return newSyntaxError(codeMisplacedDirective, "move the directive to the top of the file, before the first request",
+			"jetter directive '%s' is only allowed at the top of the file", setting).at(columnOf(line, setting))
+	default:
+		return unknownJetterDirectiveError(line, setting)
+	}
+	return nil
+}
+
+// parseSSE parses the limits of `#@jetter sse [<duration>] [<events>]`, e.g. `#@jetter sse 30s 10`.
+func parseSSE(line, arg string) (*internal.SSE, error) {
+	sse := &internal.SSE{}
+	for _, field := range strings.Fields(arg) {
+		var err error
+		if _, convErr := strconv.Atoi(field); convErr == nil {
+			if sse.Events > 0 {
+				err = fmt.Errorf("event count given twice")
+			} else {
+				sse.Events, err = parsePositiveInt(field)
+			}
+		} else if sse.Duration > 0 {
+			err = fmt.Errorf("duration given twice")
+		} else {
+			sse.Duration, err = parsePositiveDuration(field)
+		}
+		if err != nil {
+			return nil, jetterDirectiveError(line, "sse", field, err)
+		}
+	}
+	return sse, nil
+}
+
+// checkBaseURL checks that a base URL is an absolute http or https URL. Base URLs with
+// placeholders are only checked once their variables are substituted.
+func checkBaseURL(base string) error {
+	if base == "" {
+		return fmt.Errorf("missing URL")
+	}
+	if strings.Contains(base, "{{") {
+		return nil
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("'%s' is not an absolute http or https URL", base)
+	}
+	return nil
+}
+
+func splitJetterDirective(value string) (string, string) {
+	setting, arg, _ := strings.Cut(value, " ")
+	return setting, strings.TrimSpace(arg)
+}
+
+func jetterDirectiveError(line, setting, arg string, err error) error {
+	return newSyntaxError(codeInvalidDirective, "use a positive value, e.g. '#@jetter "+setting+" "+jetterDirectiveExamples[setting]+"'",
+		"invalid value for jetter directive '%s': %v", setting, err).at(columnOf(line, arg))
+}
+
+func unknownJetterDirectiveError(line, setting string) error {
+	return newSyntaxError(codeUnknownDirective, "use one of duration, concurrency, think-time, weight, strict, proto, sse, base-url or seed",
+		"unknown jetter directive '%s'", setting).at(columnOf(line, setting))
+}
+
+func parsePositiveDuration(value string) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("duration must be > 0")
+	}
+	return d, nil
+}
+
+func parsePositiveInt(value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("'%s' is not an integer", value)
+	}
+	if n <= 0 {
+		return 0, fmt.Errorf("value must be > 0")
+	}
+	return n, nil
+}
+
+// checkSSE reports an `#@jetter sse` directive in front of a request that does not
+// receive an HTTP response, like a WebSocket or gRPC request.
+func checkSSE(request internal.Request) error {
+	if request.SSE == nil {
+		return nil
+	}
+	var kind string
+	switch {
+	case request.WebSocket != nil:
+		kind = "WebSocket"
+	case request.GRPC != nil:
+		kind = "gRPC"
+	default:
+		return nil
+	}
+	return newSyntaxError(codeMisplacedDirective, "remove the '#@jetter sse' directive",
+		"jetter directive 'sse' is not supported for %s requests", kind).at(1)
+}
@@ -0,0 +1,209 @@
+package parser
+
+import (
+	"github.com/fdrolshagen/jetter/internal"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseHttp_ShouldParseRequestDirectives(t *testing.T) {
+	content := strings.TrimSpace(`
+		### Login
+		# @name Login
+		# @timeout 10
+		// @no-redirect
+		#@no-cookie-jar
+		# @no-log
+		POST http://localhost:8081/login
+
+		### Plain
+		# just a comment
+		GET http://localhost:8081/users
+		`)
+
+	c, err := ParseHttp(strings.NewReader(content))
+
+	assert.Nil(t, err)
+	assert.Empty(t, c.Warnings)
+	assert.Len(t, c.Requests, 2)
+	assert.Equal(t, "Login", c.Requests[0].Name)
+	assert.Equal(t, internal.RequestOptions{
+		Timeout:     10 * time.Second,
+		NoRedirect:  true,
+		NoCookieJar: true,
+		NoLog:       true,
+	}, c.Requests[0].Options)
+	assert.Equal(t, "Plain", c.Requests[1].Name)
+	assert.Equal(t, internal.RequestOptions{}, c.Requests[1].Options)
+}
+
+func TestParseHttp_ShouldWarnOnUnsupportedDirectives(t *testing.T) {
+	content := strings.TrimSpace(`
+		###
+		# @use-os-credentials
+		# @foo bar
+		GET http://localhost:8081/users
+		`)
+
+	c, err := ParseHttp(strings.NewReader(content))
+
+	assert.Nil(t, err)
+	assert.Len(t, c.Requests, 1)
+	assert.Equal(t, []internal.Diagnostic{
+		{
+			Severity: internal.SeverityWarning,
+			Line:     2,
+			Column:   5,
+			Code:     codeUnsupportedDirective,
+			Message:  "directive not supported by jetter '@use-os-credentials' is ignored",
+			Fix:      "remove the directive",
+		},
+		{
+			Severity: internal.SeverityWarning,
+			Line:     3,
+			Column:   5,
+			Code:     codeUnknownDirective,
+			Message:  "unknown directive '@foo' is ignored",
+			Fix:      "remove the directive or check its spelling",
+		},
+	}, c.Warnings)
+}
+
+func TestParseHttp_ShouldErrorOnInvalidTimeout(t *testing.T) {
+	content := strings.TrimSpace(`
+		###
+		# @timeout soon
+		GET http://localhost:8081/users
+		`)
+
+	_, err := ParseHttp(strings.NewReader(content))
+
+	diagnostics := requireDiagnostics(t, err)
+	assert.Contains(t, diagnostics[0].Message, "invalid value for directive '@timeout'")
+	assert.Equal(t, 2, diagnostics[0].Line)
+	assert.Equal(t, 14, diagnostics[0].Column)
+}
+
+func TestParseTimeout(t *testing.T) {
+	tests := map[string]time.Duration{
+		"10":     10 * time.Second,
+		"500ms":  500 * time.Millisecond,
+		"500 ms": 500 * time.Millisecond,
+		"2 m":    2 * time.Minute,
+		"1.5s":   1500 * time.Millisecond,
+	}
+	for value, want := range tests {
+		got, err := parseTimeout(value)
+		assert.NoError(t, err, value)
+		assert.Equal(t, want, got, value)
+	}
+
+	for _, value := range []string{"", "0", "-1", "abc"} {
+		_, err := parseTimeout(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestParseHttp_ShouldParseJetterDirectives(t *testing.T) {
+	content := strings.TrimSpace(`
+		# Load profile
+		#@jetter duration 5m
+		# @jetter concurrency 20
+		#@jetter think-time 200ms
+		#@jetter base-url https://{{stage}}.example.com/api
+		#@jetter seed 42
+		@ID = 123
+
+		### Login
+		#@jetter think-time 1s
+		POST http://localhost:8081/login
+
+		### Browse
+		#@jetter weight 3
+		GET http://localhost:8081/users
+
+		### Notifications
+		#@jetter sse 30s 10
+		GET http://localhost:8081/notifications
+		`)
+
+	c, err := ParseHttp(strings.NewReader(content))
+
+	seed := uint64(42)
+	assert.Nil(t, err)
+	assert.Equal(t, internal.ScenarioConfig{
+		Duration:    5 * time.Minute,
+		Concurrency: 20,
+		ThinkTime:   200 * time.Millisecond,
+		BaseURL:     "https://{{stage}}.example.com/api",
+		Seed:        &seed,
+	}, c.Config)
+	assert.Equal(t, "123", c.Variables["ID"])
+	assert.Len(t, c.Requests, 3)
+	assert.Equal(t, time.Second, c.Requests[0].ThinkTime)
+	assert.Equal(t, 0, c.Requests[0].Weight)
+	assert.Equal(t, 3, c.Requests[1].Weight)
+	assert.Nil(t, c.Requests[1].SSE)
+	assert.Equal(t, &internal.SSE{Duration: 30 * time.Second, Events: 10}, c.Requests[2].SSE)
+}
+
+func TestParseSSE(t *testing.T) {
+	tests := []struct {
+		arg string
+		sse internal.SSE
+	}{
+		{"", internal.SSE{}},
+		{"10s", internal.SSE{Duration: 10 * time.Second}},
+		{"5", internal.SSE{Events: 5}},
+		{"5  1m", internal.SSE{Duration: time.Minute, Events: 5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			sse, err := parseSSE("#@jetter sse "+tt.arg, tt.arg)
+			assert.Nil(t, err)
+			assert.Equal(t, &tt.sse, sse)
+		})
+	}
+}
+
+func TestParseHttp_JetterDirectiveErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		err     string
+		line    int
+	}{
+		{"unknown file directive", "#@jetter foo 1", "unknown jetter directive 'foo'", 1},
+		{"invalid duration", "#@jetter duration soon", "invalid value for jetter directive 'duration'", 1},
+		{"invalid concurrency", "#@jetter concurrency 0", "invalid value for jetter directive 'concurrency'", 1},
+		{"weight at file level", "#@jetter weight 2", "'weight' is only allowed in front of a request", 1},
+		{"duration at request level", "###\n#@jetter duration 1m\nGET http://localhost", "'duration' is only allowed at the top of the file", 2},
+		{"invalid weight", "###\n#@jetter weight many\nGET http://localhost", "invalid value for jetter directive 'weight'", 2},
+		{"unknown request directive", "###\n#@jetter foo\nGET http://localhost", "unknown jetter directive 'foo'", 2},
+		{"strict with value", "#@jetter strict yes", "unexpected value for jetter directive 'strict'", 1},
+		{"strict at request level", "###\n#@jetter strict\nGET http://localhost", "'strict' is only allowed at the top of the file", 2},
+		{"sse at file level", "#@jetter sse 10s", "'sse' is only allowed in front of a request", 1},
+		{"invalid sse limit", "###\n#@jetter sse soon\nGET http://localhost", "invalid value for jetter directive 'sse'", 2},
+		{"sse event count given twice", "###\n#@jetter sse 5 10\nGET http://localhost", "event count given twice", 2},
+		{"sse duration given twice", "###\n#@jetter sse 1s 2s\nGET http://localhost", "duration given twice", 2},
+		{"missing base URL", "#@jetter base-url", "invalid value for jetter directive 'base-url': missing URL", 1},
+		{"relative base URL", "#@jetter base-url /api", "'/api' is not an absolute http or https URL", 1},
+		{"base URL at request level", "###\n#@jetter base-url http://localhost\nGET /users", "'base-url' is only allowed at the top of the file", 2},
+		{"invalid seed", "#@jetter seed -1", "invalid value for jetter directive 'seed': '-1' is not an unsigned integer", 1},
+		{"missing seed", "#@jetter seed", "invalid value for jetter directive 'seed'", 1},
+		{"seed at request level", "###\n#@jetter seed 1\nGET http://localhost", "'seed' is only allowed at the top of the file", 2},
+		{"sse for WebSocket request", "###\n#@jetter sse\nWEBSOCKET ws://localhost", "'sse' is not supported for WebSocket requests", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseHttp(strings.NewReader(tt.content))
+			diagnostics := requireDiagnostics(t, err)
+			assert.Contains(t, diagnostics[0].Message, tt.err)
+			assert.Equal(t, tt.line, diagnostics[0].Line)
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+)
+
+// LineKind classifies a line of a Document.
+type LineKind int
+
+const (
+	LineBlank LineKind = iota
+	LineComment
+	LineVariable
+	LineImport
+	LineRun
+	LineSeparator
+	LineRequest
+	LineUrlContinuation
+	LineHeader
+	LineBody
+	LineScript
+	LineFileReference
+	LineOutputRedirect
+	// LineOther is a line that is invalid at its position. It is kept as is.
+	LineOther
+)
+
+// Line is a single line of a Document. Text does not include the line ending,
+// EOL is empty for a last line without line ending.
+type Line struct {
+	Kind LineKind
+	Text string
+	EOL  string
+}
+
+// Block is a request of a Document, starting with its `###` separator. Prelude holds the
+// comments, directives and variables in front of the request line, Request the request line with its
+// continuation lines or the run statements of the block. Body starts with the blank line
+// after the headers, Trailer holds response handler scripts, file references and output
+// redirects after the body.
+type Block struct {
+	Separator Line
+	Prelude   []Line
+	Request   []Line
+	Headers   []Line
+	Body      []Line
+	Trailer   []Line
+}
+
+// Document is a lossless representation of a .http file: Bytes returns the exact
+// content the document was parsed from. It is the basis of Format.
+type Document struct {
+	Preamble []Line
+	Blocks   []*Block
+}
+
+// ParseDocument splits src into the lines of a Document. It never fails, lines
+// that are invalid at their position are kept as LineOther.
+func ParseDocument(src []byte) *Document {
+	doc := &Document{}
+	var block *Block
+	inScript := false
+	var multipartEnd string
+
+	scanner := bufio.NewScanner(bytes.NewReader(src))
+	scanner.Buffer(nil, maxLineLength)
+	scanner.Split(scanLines)
+	for scanner.Scan() {
+		raw := scanner.Text()
+		text := strings.TrimRight(raw, "\r\n")
+		l := Line{Text: text, EOL: raw[len(text):]}
+		line := strings.TrimSpace(text)
+
+		if isNewRequest(line) {
+			l.Kind = LineSeparator
+			block = &Block{Separator: l}
+			doc.Blocks = append(doc.Blocks, block)
+			inScript = false
+			multipartEnd = ""
+			continue
+		}
+
+		switch {
+		case block == nil:
+			l.Kind = preambleKind(line)
+			doc.Preamble = append(doc.Preamble, l)
+		case len(block.Request) == 0:
+			switch {
+			case isEmptyLine(line):
+				l.Kind = LineBlank
+			case isComment(line):
+				l.Kind = LineComment
+			case isVariableDefinition(line):
+				l.Kind = LineVariable
+			case isRun(line):
+				l.Kind = LineRun
+				block.Request = append(block.Request, l)
+				continue
+			default:
+				l.Kind = LineRequest
+				block.Request = append(block.Request, l)
+				continue
+			}
+			block.Prelude = append(block.Prelude, l)
+		case block.Request[0].Kind == LineRun:
+			switch {
+			case isEmptyLine(line):
+				l.Kind = LineBlank
+			case isComment(line):
+				l.Kind = LineComment
+			case isRun(line):
+				l.Kind = LineRun
+			case isVariableDefinition(line):
+				l.Kind = LineVariable
+			default:
+				l.Kind = LineOther
+			}
+			block.Request = append(block.Request, l)
+		case len(block.Headers) == 0 && len(block.Body) == 0 && isUrlContinuation(line):
+			l.Kind = LineUrlContinuation
+			block.Request = append(block.Request, l)
+		case len(block.Body) == 0:
+			switch {
+			case isEmptyLine(line):
+				l.Kind = LineBlank
+				block.Body = append(block.Body, l)
+				multipartEnd = multipartDelimiter(block)
+				continue
+			case isComment(line):
+				l.Kind = LineComment
+			default:
+				l.Kind = LineHeader
+			}
+			block.Headers = append(block.Headers, l)
+		case inScript:
+			l.Kind = LineScript
+			inScript = !isScriptEnd(line)
+			block.Trailer = append(block.Trailer, l)
+		case multipartEnd != "" && len(block.Trailer) == 0:
+			l.Kind = LineBody
+			if line == multipartEnd {
+				multipartEnd = ""
+			}
+			block.Body = append(block.Body, l)
+		default:
+			switch {
+			case isMultilineScriptStart(line):
+				l.Kind = LineScript
+				inScript = true
+			case isSingleLineScript(line):
+				l.Kind = LineScript
+			case isResponseHandlerFile(line):
+				l.Kind = LineScript
+			case isOutputRedirect(line):
+				l.Kind = LineOutputRedirect
+			case isFileReference(line):
+				l.Kind = LineFileReference
+			case isEmptyLine(line):
+				l.Kind = LineBlank
+			case len(block.Trailer) > 0:
+				l.Kind = LineOther
+			default:
+				l.Kind = LineBody
+			}
+			if len(block.Trailer) == 0 && (l.Kind == LineBody || l.Kind == LineBlank) {
+				block.Body = append(block.Body, l)
+				continue
+			}
+			block.Trailer = append(block.Trailer, l)
+		}
+	}
+	return doc
+}
+
+func preambleKind(line string) LineKind {
+	switch {
+	case isEmptyLine(line):
+		return LineBlank
+	case isComment(line):
+		return LineComment
+	case isVariableDefinition(line):
+		return LineVariable
+	case isImport(line):
+		return LineImport
+	case isRun(line):
+		return LineRun
+	default:
+		return LineOther
+	}
+}
+
+// multipartDelimiter returns the closing delimiter of a multipart body, or an empty string if
+// the block is no multipart request. It is used to keep file references of parts in the body.
+func multipartDelimiter(block *Block) string {
+	request := newRequest()
+	for _, h := range block.Headers {
+		if h.Kind == LineHeader {
+			_ = handleHeaderLine(strings.TrimSpace(h.Text), &request)
+		}
+	}
+	if !isMultipartRequest(&request) {
+		return ""
+	}
+	mp, err := newMultipartParser(&request, "")
+	if err != nil {
+		return ""
+	}
+	return mp.delimiter + "--"
+}
+
+// Bytes returns the content of the document.
+func (d *Document) Bytes() []byte {
+	var b bytes.Buffer
+	write := func(lines ...Line) {
+		for _, l := range lines {
+			b.WriteString(l.Text)
+			b.WriteString(l.EOL)
+		}
+	}
+
+	write(d.Preamble...)
+	for _, block := range d.Blocks {
+		write(block.Separator)
+		write(block.Prelude...)
+		write(block.Request...)
+		write(block.Headers...)
+		write(block.Body...)
+		write(block.Trailer...)
+	}
+	return b.Bytes()
+}
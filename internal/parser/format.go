@@ -0,0 +1,269 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/textproto"
+	"sort"
+	"strings"
+)
+
+// Format rewrites the .http file src in the canonical style:
+//
+//   - requests are separated by `###` and a single blank line
+//   - top-level variables are sorted by name and written as `@name = value`
+//   - request lines, URL continuation lines and headers are normalized in spacing,
+//     header names use their canonical casing
+//   - bodies are separated from the headers by a single blank line, and JSON bodies
+//     are pretty-printed if the Content-Type is JSON
+//
+// Comments, directives, scripts and the content of other bodies are kept as is.
+func Format(src []byte) []byte {
+	doc := ParseDocument(src)
+	f := &formatter{eol: documentEOL(doc)}
+	f.preamble(doc.Preamble)
+	for _, block := range doc.Blocks {
+		f.block(block)
+	}
+	if f.buf.Len() == 0 {
+		return nil
+	}
+	return f.buf.Bytes()
+}
+
+// formatter writes lines separated by at most one blank line.
+type formatter struct {
+	buf   bytes.Buffer
+	eol   string
+	blank bool
+}
+
+// line writes text, preceded by a blank line if one is pending.
+func (f *formatter) line(text string) {
+	f.raw(text, f.eol)
+}
+
+// raw writes text with the given line ending, which is kept for the content of bodies.
+func (f *formatter) raw(text, eol string) {
+	if f.blank && f.buf.Len() > 0 {
+		f.buf.WriteString(f.eol)
+	}
+	f.blank = false
+	if eol == "" {
+		eol = f.eol
+	}
+	f.buf.WriteString(text)
+	f.buf.WriteString(eol)
+}
+
+// separate requests a blank line before the next line that is written.
+func (f *formatter) separate() {
+	f.blank = true
+}
+
+// variable is a top-level variable definition along with the comments directly above it.
+type variable struct {
+	name  string
+	lines []string
+}
+
+func (f *formatter) preamble(lines []Line) {
+	vars, attached := collectVariables(lines)
+	written := false
+	for i, l := range lines {
+		if attached[i] {
+			continue
+		}
+		switch l.Kind {
+		case LineBlank:
+			f.separate()
+		case LineVariable:
+			if !written {
+				for _, v := range vars {
+					for _, text := range v.lines {
+						f.line(text)
+					}
+				}
+				written = true
+			}
+		case LineImport, LineRun:
+			f.line(strings.Join(strings.Fields(l.Text), " "))
+		default:
+			f.line(strings.TrimSpace(l.Text))
+		}
+	}
+}
+
+// collectVariables returns the variable definitions of the preamble sorted by name, and marks
+// the lines that are written along with them: the definitions and the comments directly above.
+func collectVariables(lines []Line) ([]variable, map[int]bool) {
+	var vars []variable
+	attached := make(map[int]bool)
+	for i, l := range lines {
+		if l.Kind != LineVariable {
+			continue
+		}
+
+		start := i
+		for start > 0 && lines[start-1].Kind == LineComment && !isDirectiveLine(lines[start-1].Text) && !attached[start-1] {
+			start--
+		}
+		v := variable{name: variableName(l.Text)}
+		for j := start; j < i; j++ {
+			v.lines = append(v.lines, strings.TrimSpace(lines[j].Text))
+			attached[j] = true
+		}
+		v.lines = append(v.lines, formatVariable(l.Text))
+		vars = append(vars, v)
+	}
+
+	sort.SliceStable(vars, func(i, j int) bool { return vars[i].name < vars[j].name })
+	return vars, attached
+}
+
+func isDirectiveLine(text string) bool {
+	_, _, ok := parseDirective(strings.TrimSpace(text))
+	return ok
+}
+
+func variableName(text string) string {
+	name, _, _ := strings.Cut(strings.TrimSpace(text), "=")
+	return strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(name), "@"))
+}
+
+func formatVariable(text string) string {
+	line := strings.TrimSpace(text)
+	vars := map[string]string{}
+	name, err := handleVariableDefinition(line, vars)
+	if err != nil {
+		return line
+	}
+	return "@" + name + " = " + vars[name]
+}
+
+func (f *formatter) block(b *Block) {
+	f.separate()
+	if name := strings.TrimSpace(strings.TrimLeft(b.Separator.Text, "#")); name != "" {
+		f.line("### " + name)
+	} else {
+		f.line("###")
+	}
+
+	// Variables of a block keep their order, as later definitions shadow earlier ones.
+	for _, l := range b.Prelude {
+		switch l.Kind {
+		case LineBlank:
+		case LineVariable:
+			f.line(formatVariable(l.Text))
+		default:
+			f.line(strings.TrimSpace(l.Text))
+		}
+	}
+
+	for _, l := range b.Request {
+		switch l.Kind {
+		case LineBlank:
+			if b.Request[0].Kind == LineRun {
+				f.separate()
+			}
+		case LineRequest, LineRun:
+			f.line(strings.Join(strings.Fields(l.Text), " "))
+		case LineUrlContinuation:
+			f.line("    " + strings.Join(strings.Fields(l.Text), " "))
+		case LineVariable:
+			f.line(formatVariable(l.Text))
+		default:
+			f.line(strings.TrimSpace(l.Text))
+		}
+	}
+
+	headers := map[string]string{}
+	for _, l := range b.Headers {
+		text := strings.TrimSpace(l.Text)
+		key, value, ok := strings.Cut(text, ":")
+		if l.Kind != LineHeader || !ok {
+			f.line(text)
+			continue
+		}
+		key = textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		headers[key] = value
+		f.line(key + ": " + value)
+	}
+
+	body := trimBlankLines(b.Body)
+	if len(body) > 0 {
+		f.separate()
+		if pretty, ok := prettyJson(body, headers); ok {
+			for _, text := range strings.Split(pretty, "\n") {
+				f.line(text)
+			}
+		} else {
+			for _, l := range body {
+				f.raw(l.Text, l.EOL)
+			}
+		}
+	}
+
+	for i, l := range b.Trailer {
+		if i == 0 || l.Kind == LineBlank {
+			f.separate()
+		}
+		switch l.Kind {
+		case LineBlank:
+		case LineScript:
+			if isMultilineScriptStart(strings.TrimSpace(l.Text)) || isSingleLineScript(strings.TrimSpace(l.Text)) {
+				f.line(strings.TrimSpace(l.Text))
+			} else {
+				f.line(strings.TrimRight(l.Text, " \t"))
+			}
+		default:
+			f.line(strings.TrimSpace(l.Text))
+		}
+	}
+}
+
+// trimBlankLines removes the blank lines around a body.
+func trimBlankLines(lines []Line) []Line {
+	start, end := 0, len(lines)
+	for start < end && strings.TrimSpace(lines[start].Text) == "" {
+		start++
+	}
+	for end > start && strings.TrimSpace(lines[end-1].Text) == "" {
+		end--
+	}
+	return lines[start:end]
+}
+
+// prettyJson indents the body if the Content-Type is JSON and the body is valid JSON.
+// Bodies with placeholders outside of JSON strings are left as they are.
+func prettyJson(body []Line, headers map[string]string) (string, bool) {
+	mediaType, _, err := mime.ParseMediaType(headers["Content-Type"])
+	if err != nil || (mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json")) {
+		return "", false
+	}
+
+	var raw strings.Builder
+	for _, l := range body {
+		raw.WriteString(l.Text)
+		raw.WriteString("\n")
+	}
+	var pretty bytes.Buffer
+	if err := json.Indent(&pretty, []byte(raw.String()), "", "  "); err != nil {
+		return "", false
+	}
+	return strings.TrimSpace(pretty.String()), true
+}
+
+// documentEOL returns the line ending of the first line of the document, which is used for all lines written by Format.
+func documentEOL(doc *Document) string {
+	first := doc.Preamble
+	if len(first) == 0 && len(doc.Blocks) > 0 {
+		first = []Line{doc.Blocks[0].Separator}
+	}
+	if len(first) > 0 && first[0].EOL == "\r\n" {
+		return "\r\n"
+	}
+	return "\n"
+}
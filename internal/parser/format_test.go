@@ -0,0 +1,95 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/fdrolshagen/jetter/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFormat_Golden(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "format", "*.http"))
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			src, err := os.ReadFile(file)
+			require.NoError(t, err)
+			golden, err := os.ReadFile(strings.TrimSuffix(file, ".http") + ".golden")
+			require.NoError(t, err)
+
+			formatted := Format(src)
+
+			assert.Equal(t, string(golden), string(formatted))
+			assert.Equal(t, string(formatted), string(Format(formatted)), "formatting is not idempotent")
+		})
+	}
+}
+
+func TestParseDocument_ShouldBeLossless(t *testing.T) {
+	var files []string
+	for _, pattern := range []string{"testdata/*/*.http", "../../examples/*.http"} {
+		matches, err := filepath.Glob(pattern)
+		require.NoError(t, err)
+		files = append(files, matches...)
+	}
+	require.NotEmpty(t, files)
+
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		require.NoError(t, err)
+
+		assert.Equal(t, string(src), string(ParseDocument(src).Bytes()), file)
+	}
+}
+
+func TestFormat_ShouldKeepRequests(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*", "*.http"))
+	require.NoError(t, err)
+
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		require.NoError(t, err)
+		if bytes.Contains(src, []byte("import")) || bytes.Contains(src, []byte("run ")) {
+			continue
+		}
+
+		before, err := ParseHttp(bytes.NewReader(src))
+		require.NoError(t, err, file)
+		after, err := ParseHttp(bytes.NewReader(Format(src)))
+		require.NoError(t, err, file)
+
+		require.Len(t, after.Requests, len(before.Requests), file)
+		for i := range before.Requests {
+			b, a := before.Requests[i], after.Requests[i]
+			assert.Equal(t, b.Name, a.Name, file)
+			assert.Equal(t, b.Method, a.Method, file)
+			assert.Equal(t, b.Url, a.Url, file)
+			assert.Equal(t, compactJson(b.Body), compactJson(a.Body), file)
+			assert.Equal(t, b.ResponseHandler, a.ResponseHandler, file)
+			assert.Len(t, a.Headers, len(b.Headers), file)
+			assert.Equal(t, internal.ScopeAt(before.ScopedVariables, i), internal.ScopeAt(after.ScopedVariables, i), file)
+		}
+		assert.Equal(t, before.Variables, after.Variables, file)
+	}
+}
+
+// compactJson removes the whitespace of JSON bodies, which Format pretty-prints.
+func compactJson(body string) string {
+	var b bytes.Buffer
+	if err := json.Compact(&b, []byte(body)); err != nil {
+		return body
+	}
+	return b.String()
+}
+
+func TestFormat_ShouldHandleEmptyInput(t *testing.T) {
+	assert.Empty(t, Format(nil))
+	assert.Empty(t, Format([]byte("\n\n")))
+}
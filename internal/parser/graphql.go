@@ -0,0 +1,99 @@
+package parser
+
+import (
+	"github.com/fdrolshagen/jetter/internal"
+	"regexp"
+	"strings"
+)
+
+// graphQLMethod is the pseudo method of IntelliJ GraphQL requests.
+const graphQLMethod = "GRAPHQL"
+
+var operationRegex = regexp.MustCompile(`(?m)^\s*(?:query|mutation|subscription)\s+([_A-Za-z][_0-9A-Za-z]*)`)
+
+// newGraphQL splits the body of a GraphQL request into the query and the JSON variables
+// following it. The operation name is taken from the first named operation of the query.
+func newGraphQL(body string) *internal.GraphQL {
+	query, variables := splitGraphQL(body)
+	g := &internal.GraphQL{
+		Query:     strings.TrimSpace(query),
+		Variables: strings.TrimSpace(variables),
+	}
+	if m := operationRegex.FindStringSubmatch(g.Query); m != nil {
+		g.OperationName = m[1]
+	}
+	return g
+}
+
+// splitGraphQL returns the query and the variables of a GraphQL request body. The variables
+// are the last top-level block starting with '{' that follows another definition, so that
+// a query in shorthand form like `{ users { id } }` is not taken as variables.
+// Strings and comments are skipped, placeholders like {{id}} keep the braces balanced.
+// JSON has no comments, so skipping them within the variables does no harm.
+func splitGraphQL(body string) (string, string) {
+	depth := 0
+	// inDefinition is set while the current top-level definition has content.
+	inDefinition := false
+	definitions := 0
+	start := -1
+
+	for i := 0; i < len(body); i++ {
+		switch c := body[i]; {
+		case c == '#':
+			i = skipUntil(body, i, "\n")
+		case c == '"' && strings.HasPrefix(body[i:], `"""`):
+			i = skipUntil(body, i+3, `"""`)
+		case c == '"':
+			i = skipString(body, i)
+		case c == '{':
+			if depth == 0 && !inDefinition {
+				if definitions > 0 {
+					start = i
+				}
+				definitions++
+			}
+			inDefinition = true
+			depth++
+		case c == '}':
+			depth = max(depth-1, 0)
+			if depth == 0 {
+				inDefinition = false
+			}
+		case depth == 0 && !isGraphQLSpace(c) && !inDefinition:
+			inDefinition = true
+			definitions++
+		}
+	}
+
+	if start < 0 {
+		return body, ""
+	}
+	return body[:start], body[start:]
+}
+
+// skipUntil returns the index of the last byte of the first occurrence of end after i,
+// or the index of the last byte of s if there is none.
+func skipUntil(s string, i int, end string) int {
+	j := strings.Index(s[i:], end)
+	if j < 0 {
+		return len(s) - 1
+	}
+	return i + j + len(end) - 1
+}
+
+// skipString returns the index of the closing quote of the string starting at i.
+func skipString(s string, i int) int {
+	for j := i + 1; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case '"', '\n':
+			return j
+		}
+	}
+	return len(s) - 1
+}
+
+func isGraphQLSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == ','
+}
@@ -0,0 +1,94 @@
+package parser
+
+import (
+	"github.com/fdrolshagen/jetter/internal"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestSplitGraphQL(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		query     string
+		variables string
+	}{
+		{"query only", "query { users { id } }", "query { users { id } }", ""},
+		{"shorthand query", "{ users { id } }", "{ users { id } }", ""},
+		{"shorthand query with variables", "{ users { id } }\n\n{\"a\": 1}", "{ users { id } }\n\n", `{"a": 1}`},
+		{
+			"query with variables",
+			"query User($id: ID!) {\n  user(id: $id) { name }\n}\n\n{\n  \"id\": \"{{ID}}\"\n}",
+			"query User($id: ID!) {\n  user(id: $id) { name }\n}\n\n",
+			"{\n  \"id\": \"{{ID}}\"\n}",
+		},
+		{"placeholder as value", "query ($id: ID!) { user(id: $id) { name } }\n{\"id\": {{ID}}}", "query ($id: ID!) { user(id: $id) { name } }\n", `{"id": {{ID}}}`},
+		{"placeholder as variables", "query ($id: ID!) { user(id: $id) { name } }\n{{VARS}}", "query ($id: ID!) { user(id: $id) { name } }\n", "{{VARS}}"},
+		{"braces in strings and comments", "query {\n  # } {\n  users(filter: \"}{\", doc: \"\"\"{\n}\"\"\") { id }\n}\n{\"filter\": \"}\"}", "query {\n  # } {\n  users(filter: \"}{\", doc: \"\"\"{\n}\"\"\") { id }\n}\n", `{"filter": "}"}`},
+		{"fragments", "query { ...F }\nfragment F on User { id }\n{}", "query { ...F }\nfragment F on User { id }\n", "{}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, variables := splitGraphQL(tt.body)
+			assert.Equal(t, tt.query, query)
+			assert.Equal(t, tt.variables, variables)
+		})
+	}
+}
+
+func TestParseHttp_ShouldParseGraphQLRequest(t *testing.T) {
+	content := strings.TrimSpace(`
+		### Get User
+		GRAPHQL {{URL}}/graphql
+		Authorization: Bearer {{token}}
+
+		# Fetch a single user
+		query GetUser($id: ID!) {
+		  user(id: $id) { name }
+		}
+
+		{
+		  "id": "{{ID}}"
+		}
+
+		> {% client.test("ok", function() {}); %}
+
+		### Anonymous
+		GRAPHQL {{URL}}/graphql
+
+		{ users { id } }
+		`)
+
+	c, err := ParseHttp(strings.NewReader(content))
+
+	assert.Nil(t, err)
+	assert.Len(t, c.Requests, 2)
+	r := c.Requests[0]
+	assert.Equal(t, "POST", r.Method)
+	assert.Equal(t, "{{URL}}/graphql", r.Url)
+	assert.Empty(t, r.Body)
+	assert.NotEmpty(t, r.ResponseHandler)
+	assert.Equal(t, &internal.GraphQL{
+		Query:         "# Fetch a single user\n\t\tquery GetUser($id: ID!) {\n\t\t  user(id: $id) { name }\n\t\t}",
+		OperationName: "GetUser",
+		Variables:     "{\n\t\t  \"id\": \"{{ID}}\"\n\t\t}",
+	}, r.GraphQL)
+	assert.Equal(t, &internal.GraphQL{Query: "{ users { id } }"}, c.Requests[1].GraphQL)
+}
+
+func TestParseHttp_ShouldErrorOnGraphQLFileReference(t *testing.T) {
+	content := strings.TrimSpace(`
+		###
+		GRAPHQL http://localhost:8081/graphql
+
+		< ./query.graphql
+		`)
+
+	_, err := ParseHttp(strings.NewReader(content))
+
+	diagnostics := requireDiagnostics(t, err)
+	assert.Equal(t, "GraphQL requests cannot reference a file", diagnostics[0].Message)
+	assert.Equal(t, 4, diagnostics[0].Line)
+}
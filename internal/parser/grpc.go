@@ -0,0 +1,22 @@
+package parser
+
+import (
+	"github.com/fdrolshagen/jetter/internal"
+	"strings"
+)
+
+// grpcMethod is the pseudo method of IntelliJ gRPC requests.
+const grpcMethod = "GRPC"
+
+// checkGRPCTarget checks the URL of a gRPC request. URLs with placeholders are only
+// checked once their variables are substituted.
+func checkGRPCTarget(line string, url string) error {
+	if strings.Contains(url, "{{") {
+		return nil
+	}
+	if _, err := internal.ParseGRPCTarget(url); err != nil {
+		return newSyntaxError(codeInvalidRequestLine, "use 'GRPC <host>:<port>/<package.Service>/<Method>'",
+			"invalid gRPC target '%s': %v", url, err).at(columnOf(line, url))
+	}
+	return nil
+}
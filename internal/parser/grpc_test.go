@@ -0,0 +1,79 @@
+package parser
+
+import (
+	"github.com/fdrolshagen/jetter/internal"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseHttp_ShouldParseGRPCRequest(t *testing.T) {
+	content := strings.TrimSpace(`
+		### Say Hello
+		GRPC localhost:9090/jetter.test.Greeter/SayHello
+		X-Greeting: Hi
+
+		{"name": "{{NAME}}"}
+
+		### Next
+		GET http://localhost:8080/users
+		`)
+
+	c, err := ParseHttp(strings.NewReader(content))
+
+	assert.Nil(t, err)
+	assert.Len(t, c.Requests, 2)
+	r := c.Requests[0]
+	assert.Equal(t, "POST", r.Method)
+	assert.Equal(t, "localhost:9090/jetter.test.Greeter/SayHello", r.Url)
+	assert.Equal(t, "Hi", r.Headers["X-Greeting"])
+	assert.Equal(t, "\t\t{\"name\": \"{{NAME}}\"}", r.Body)
+	assert.Equal(t, &internal.GRPC{}, r.GRPC)
+	assert.Nil(t, c.Requests[1].GRPC)
+}
+
+func TestParseHttpFile_ShouldResolveProtosRelativeToFile(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"scenario.http": `
+			#@jetter proto ./protos/greeter.proto
+			#@jetter proto /opt/descriptors.protoset
+
+			### Say Hello
+			GRPC grpcs://localhost:9090/jetter.test.Greeter/SayHello
+			`,
+	})
+
+	c, err := ParseHttpFile(filepath.Join(dir, "scenario.http"))
+
+	assert.Nil(t, err)
+	protos := []string{filepath.Join(dir, "protos", "greeter.proto"), "/opt/descriptors.protoset"}
+	assert.Equal(t, protos, c.Config.Protos)
+	assert.Equal(t, &internal.GRPC{Protos: protos}, c.Requests[0].GRPC)
+}
+
+func TestParseHttp_GRPCErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		err     string
+		line    int
+		column  int
+	}{
+		{"missing method", "###\nGRPC localhost:9090/jetter.test.Greeter", "invalid gRPC target 'localhost:9090/jetter.test.Greeter'", 2, 6},
+		{"unsupported scheme", "###\nGRPC ftp://localhost:9090/jetter.test.Greeter/SayHello", "invalid gRPC target 'ftp://localhost:9090/jetter.test.Greeter/SayHello'", 2, 6},
+		{"file reference", "###\nGRPC localhost:9090/jetter.test.Greeter/SayHello\n\n< ./hello.json", "gRPC requests cannot reference a file", 4, 1},
+		{"missing proto path", "#@jetter proto", "missing path for jetter directive 'proto'", 1, 15},
+		{"proto at request level", "###\n#@jetter proto ./greeter.proto\nGRPC localhost:9090/jetter.test.Greeter/SayHello", "jetter directive 'proto' is only allowed at the top of the file", 2, 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseHttp(strings.NewReader(tt.content))
+			diagnostics := requireDiagnostics(t, err)
+			assert.Contains(t, diagnostics[0].Message, tt.err)
+			assert.Equal(t, tt.line, diagnostics[0].Line)
+			assert.Equal(t, tt.column, diagnostics[0].Column)
+		})
+	}
+}
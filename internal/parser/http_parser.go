@@ -6,6 +6,8 @@ import (
 	"github.com/fdrolshagen/jetter/internal"
 	"io"
 	"os"
+	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -21,33 +23,76 @@ const (
 	StateBodyPartRead
 	StateIgnoredBodyPartRead
 	StateMultilineScriptStarted
+	StateMultipartBodyRead
+	StateRunRead
 )
 
-var methods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
+// standardMethods are the methods of RFC 9110, PATCH of RFC 5789 and the WebDAV methods of RFC 4918.
+// In strict mode, other methods are reported as warnings.
+var standardMethods = []string{
+	"GET", "HEAD", "POST", "PUT", "DELETE", "CONNECT", "OPTIONS", "TRACE", "PATCH",
+	"PROPFIND", "PROPPATCH", "MKCOL", "COPY", "MOVE", "LOCK", "UNLOCK",
+}
+
+// Options control how a .http file is parsed.
+type Options struct {
+	// Strict reports requests with a non-standard method as warnings,
+	// like the `#@jetter strict` directive.
+	Strict bool
+}
 
+// ParseHttpFile parses the .http file with the given name. Imported files and
+// files referenced by the requests are resolved relative to its directory.
+//
+// Parsing does not stop at the first problem. If the file contains errors, all
+// diagnostics of the file and its imports are returned as internal.Diagnostics.
 func ParseHttpFile(filename string) (internal.Collection, error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return internal.Collection{}, err
-	}
-	defer file.Close()
+	return ParseHttpFileWithOptions(filename, Options{})
+}
 
-	return ParseHttp(file)
+// ParseHttpFileWithOptions parses the .http file with the given name like ParseHttpFile.
+// The options apply to the file and to everything it imports.
+func ParseHttpFileWithOptions(filename string, opts Options) (internal.Collection, error) {
+	imp := newImporter()
+	imp.options = opts
+	return imp.parseFile(filename)
 }
 
+// ParseHttp parses a collection from r like ParseHttpFile. Imported files and files
+// referenced by the requests are resolved relative to the current working directory.
 func ParseHttp(r io.Reader) (internal.Collection, error) {
+	return parseHttp(r, "", ".", newImporter())
+}
+
+func parseHttp(r io.Reader, file string, dir string, imp *importer) (internal.Collection, error) {
 	var requests []internal.Request
 	var vars = map[string]string{}
+	var positions = map[string]internal.Position{}
+	var scoped []internal.ScopedVariable
+	var config internal.ScenarioConfig
+	imported := internal.Collection{Variables: map[string]string{}, VariablePositions: map[string]internal.Position{}}
+	diags := &diagnostics{file: file}
 
 	state := StateParsingStarted
 	lineCounter := 0
 	request := newRequest()
+	var multipartBody *multipartParser
 
 	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxLineLength)
+	scanner.Split(scanLines)
 	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
+		raw := scanner.Text()
+		line := strings.TrimSpace(raw)
 		lineCounter++
 
+		if isNewRequest(line) && multipartBody != nil {
+			if err := multipartBody.finish(); err != nil {
+				diags.add(err, raw, lineCounter)
+			}
+			multipartBody = nil
+		}
+
 		if handleNewRequest(line, &requests, &request) {
 			state = StateInitialConfigLineRead
 			continue
@@ -55,15 +100,83 @@ func ParseHttp(r io.Reader) (internal.Collection, error) {
 
 		switch state {
 		case StateParsingStarted:
-			if err := handleVariableDefinition(line, vars, lineCounter); err != nil {
-				return internal.Collection{}, err
+			if isImport(line) {
+				if err := handleImport(line, dir, imp, &imported); err != nil {
+					diags.add(err, raw, lineCounter)
+				}
+				continue
+			}
+			if isRun(line) {
+				run, err := handleRun(line, dir, imp, requests, &imported)
+				if err != nil {
+					diags.add(err, raw, lineCounter)
+				}
+				requests = append(requests, run...)
+				continue
+			}
+			if isComment(line) {
+				if err := handleFileDirective(line, dir, &config); err != nil {
+					diags.add(err, raw, lineCounter)
+				}
+				continue
+			}
+			key, err := handleVariableDefinition(line, vars)
+			if err != nil {
+				diags.add(err, raw, lineCounter)
+			} else if key != "" {
+				positions[key] = internal.Position{File: file, Line: lineCounter}
 			}
 		case StateInitialConfigLineRead:
+			if isEmptyLine(line) {
+				continue
+			}
+			if isComment(line) {
+				if err := handleRequestDirective(line, &request); err != nil {
+					diags.add(err, raw, lineCounter)
+				}
+				continue
+			}
+			if isVariableDefinition(line) {
+				if err := handleScopedVariable(line, len(requests), internal.Position{File: file, Line: lineCounter}, &scoped); err != nil {
+					diags.add(err, raw, lineCounter)
+				}
+				continue
+			}
+			if isRun(line) {
+				run, err := handleRun(line, dir, imp, requests, &imported)
+				if err != nil {
+					diags.add(err, raw, lineCounter)
+				}
+				requests = append(requests, run...)
+				state = StateRunRead
+				continue
+			}
+			if isImport(line) {
+				diags.add(misplacedStatementError("import"), raw, lineCounter)
+				continue
+			}
 			if err := handleRequestLine(line, &request, lineCounter); err != nil {
-				return internal.Collection{}, err
+				diags.add(err, raw, lineCounter)
+			} else if imp.options.Strict || config.Strict {
+				if err := checkStandardMethod(line, request.Method); err != nil {
+					diags.add(err, raw, lineCounter)
+				}
+			}
+			if request.GRPC != nil {
+				request.GRPC.Protos = config.Protos
 			}
+			if err := checkSSE(request); err != nil {
+				diags.add(err, raw, lineCounter)
+			}
+			request.File = file
 			state = StateHttpConfigLineRead
 		case StateHttpConfigLineRead, StateHttpHeaderRead:
+			if state == StateHttpConfigLineRead && isUrlContinuation(line) {
+				if err := handleUrlContinuation(line, &request); err != nil {
+					diags.add(err, raw, lineCounter)
+				}
+				continue
+			}
 			if isHeaderBodySeparation(line) {
 				state = StateHeaderBodySeparationRead
 				break
@@ -71,34 +184,177 @@ func ParseHttp(r io.Reader) (internal.Collection, error) {
 			if isComment(line) {
 				continue
 			}
-			if err := handleHeaderLine(line, &request, lineCounter); err != nil {
-				return internal.Collection{}, err
+			if isImport(line) || isRun(line) {
+				diags.add(misplacedStatementError(strings.Fields(line)[0]), raw, lineCounter)
+				continue
+			}
+			if err := handleHeaderLine(line, &request); err != nil {
+				diags.add(err, raw, lineCounter)
+				continue
 			}
+			request.HeaderLines[headerName(line)] = lineCounter
 			state = StateHttpHeaderRead
 		case StateHeaderBodySeparationRead, StateBodyPartRead, StateIgnoredBodyPartRead:
+			if request.BodyLine == 0 && !isEmptyLine(line) {
+				request.BodyLine = lineCounter
+			}
+			if request.Multipart == nil && !isEmptyLine(line) && !isFileReference(line) && isMultipartRequest(&request) {
+				var err error
+				if multipartBody, err = newMultipartParser(&request, dir); err != nil {
+					diags.add(err, raw, lineCounter)
+					state = StateIgnoredBodyPartRead
+					continue
+				}
+				state = StateMultipartBodyRead
+				if _, err := multipartBody.add(line, raw, lineCounter); err != nil {
+					diags.add(err, raw, lineCounter)
+				}
+				continue
+			}
 			if isMultilineScriptStart(line) {
+				appendScriptLine(&request, strings.TrimSpace(strings.TrimPrefix(line, "> {%")))
 				state = StateMultilineScriptStarted
 				continue
 			}
-			if isEmptyLine(line) || isScriptOrFile(line) {
+			if isSingleLineScript(line) {
+				handleSingleLineScript(line, &request)
 				state = StateIgnoredBodyPartRead
 				continue
 			}
-			request.Body += line + "\n"
+			if isFileReference(line) && request.GraphQL != nil {
+				diags.add(newSyntaxError(codeBodyConflict, "write the query inline, followed by the variables as JSON object",
+					"GraphQL requests cannot reference a file"), raw, lineCounter)
+				state = StateIgnoredBodyPartRead
+				continue
+			}
+			if isFileReference(line) && request.GRPC != nil {
+				diags.add(newSyntaxError(codeBodyConflict, "write the request messages inline as JSON objects",
+					"gRPC requests cannot reference a file"), raw, lineCounter)
+				state = StateIgnoredBodyPartRead
+				continue
+			}
+			if isFileReference(line) && request.WebSocket != nil {
+				diags.add(newSyntaxError(codeBodyConflict, "write the messages inline, separated by '==='",
+					"WebSocket requests cannot reference a file"), raw, lineCounter)
+				state = StateIgnoredBodyPartRead
+				continue
+			}
+			if request.WebSocket != nil && isMessageSeparator(line) {
+				if err := handleMessageSeparator(line); err != nil {
+					diags.add(err, raw, lineCounter)
+					continue
+				}
+				request.Body += raw
+				state = StateBodyPartRead
+				continue
+			}
+			if isFileReference(line) {
+				if err := handleFileReference(line, dir, &request); err != nil {
+					diags.add(err, raw, lineCounter)
+				}
+				state = StateIgnoredBodyPartRead
+				continue
+			}
+			if isOutputRedirect(line) {
+				if err := handleOutputRedirect(line, dir, &request); err != nil {
+					diags.add(err, raw, lineCounter)
+				}
+				state = StateIgnoredBodyPartRead
+				continue
+			}
+			if isEmptyLine(line) {
+				if request.Body != "" {
+					request.Body += raw
+				}
+				continue
+			}
+			if isResponseHandlerFile(line) {
+				if err := handleResponseHandlerFile(line, dir, &request); err != nil {
+					diags.add(err, raw, lineCounter)
+				}
+				state = StateIgnoredBodyPartRead
+				continue
+			}
+			if isPreRequestScript(line) {
+				state = StateIgnoredBodyPartRead
+				continue
+			}
+			if request.BodyFile != nil {
+				diags.add(newSyntaxError(codeBodyConflict, "move the content into the referenced file or remove the file reference",
+					"request body cannot combine inline content and a file reference"), raw, lineCounter)
+				continue
+			}
+			if request.Multipart != nil {
+				diags.add(newSyntaxError(codeUnexpectedContent, "move the content into a part before the closing boundary",
+					"unexpected content after closing multipart boundary"), raw, lineCounter)
+				continue
+			}
+			request.Body += raw
 			state = StateBodyPartRead
+		case StateRunRead:
+			if isEmptyLine(line) || isComment(line) {
+				continue
+			}
+			if isVariableDefinition(line) {
+				if err := handleScopedVariable(line, len(requests), internal.Position{File: file, Line: lineCounter}, &scoped); err != nil {
+					diags.add(err, raw, lineCounter)
+				}
+				continue
+			}
+			if !isRun(line) {
+				diags.add(newSyntaxError(codeUnexpectedContent, "start a new request with '###' before the request line",
+					"expected run statement or new request"), raw, lineCounter)
+				continue
+			}
+			run, err := handleRun(line, dir, imp, requests, &imported)
+			if err != nil {
+				diags.add(err, raw, lineCounter)
+			}
+			requests = append(requests, run...)
+		case StateMultipartBodyRead:
+			closed, err := multipartBody.add(line, raw, lineCounter)
+			if err != nil {
+				diags.add(err, raw, lineCounter)
+			}
+			if closed {
+				multipartBody = nil
+				state = StateIgnoredBodyPartRead
+			}
 		case StateMultilineScriptStarted:
 			if isScriptEnd(line) {
+				appendScriptLine(&request, strings.TrimSpace(strings.TrimSuffix(line, "%}")))
 				state = StateIgnoredBodyPartRead
+				continue
 			}
+			appendScriptLine(&request, line)
 		default:
 			return internal.Collection{}, fmt.Errorf("parsing error: invalid internal state")
 		}
 	}
+	if err := scanner.Err(); err != nil {
+		return internal.Collection{}, err
+	}
+
+	if multipartBody != nil {
+		if err := multipartBody.finish(); err != nil {
+			diags.add(err, "", lineCounter)
+		}
+	}
+
+	if err := diags.errors(); err != nil {
+		return internal.Collection{}, err
+	}
 
 	appendAndReset(&requests, &request)
+	mergeVariables(vars, imported.Variables)
+	mergeVariables(positions, imported.VariablePositions)
 	return internal.Collection{
-		Requests:  requests,
-		Variables: vars,
+		Requests:          requests,
+		Variables:         vars,
+		VariablePositions: positions,
+		ScopedVariables:   scoped,
+		Config:            config,
+		Warnings:          append(imported.Warnings, diags.warnings()...),
 	}, nil
 }
 
@@ -114,35 +370,161 @@ func isHeaderBodySeparation(line string) bool {
 	return line == "\n" || line == ""
 }
 
+func isUrlContinuation(line string) bool {
+	return strings.HasPrefix(line, "?") || strings.HasPrefix(line, "&")
+}
+
 func isComment(line string) bool {
-	return strings.HasPrefix(line, "#")
+	return strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//")
+}
+
+func isPreRequestScript(line string) bool {
+	return strings.HasPrefix(line, "< {%")
+}
+
+// isResponseHandlerFile reports whether line is '> path.js', a response handler kept in
+// a file. Other lines starting with '>', such as quoted text, are body content.
+func isResponseHandlerFile(line string) bool {
+	rest, ok := strings.CutPrefix(line, ">")
+	if !ok || rest == "" || (rest[0] != ' ' && rest[0] != '\t') {
+		return false
+	}
+	path := strings.TrimSpace(rest)
+	return strings.HasSuffix(path, ".js") && !strings.ContainsAny(path, " \t")
+}
+
+// isFileReference reports whether line is '< path' or '<@ path'. Other lines starting
+// with '<', such as XML or HTML markup, are body content.
+func isFileReference(line string) bool {
+	rest, ok := strings.CutPrefix(line, "<")
+	if !ok {
+		return false
+	}
+	rest = strings.TrimPrefix(rest, "@")
+	if rest == "" {
+		return true
+	}
+	return (rest[0] == ' ' || rest[0] == '\t') && !strings.HasPrefix(strings.TrimSpace(rest), "{%")
 }
 
-func isScriptOrFile(line string) bool {
-	return strings.HasPrefix(line, ">") || strings.HasPrefix(line, "<")
+func isOutputRedirect(line string) bool {
+	return strings.HasPrefix(line, ">>")
 }
 
 func isMultilineScriptStart(line string) bool {
 	return strings.HasPrefix(line, "> {%") && !strings.HasSuffix(line, "%}")
 }
 
+func isSingleLineScript(line string) bool {
+	return strings.HasPrefix(line, "> {%") && strings.HasSuffix(line, "%}")
+}
+
 func isScriptEnd(line string) bool {
 	return strings.HasSuffix(line, "%}")
 }
 
-func handleVariableDefinition(line string, vars map[string]string, lineCounter int) error {
+func handleSingleLineScript(line string, request *internal.Request) {
+	script := strings.TrimPrefix(line, "> {%")
+	script = strings.TrimSuffix(script, "%}")
+	appendScriptLine(request, strings.TrimSpace(script))
+}
+
+func appendScriptLine(request *internal.Request, line string) {
+	if line == "" {
+		return
+	}
+	request.ResponseHandler += line + "\n"
+}
+
+// handleVariableDefinition adds the variable defined by line to vars and returns its name.
+func handleVariableDefinition(line string, vars map[string]string) (string, error) {
 	if !isVariableDefinition(line) {
-		return nil
+		return "", nil
 	}
 
 	parts := strings.Split(line, "=")
 	if len(parts) != 2 {
-		return fmt.Errorf("parsing error: invalid variable definition at line %d", lineCounter)
+		return "", newSyntaxError(codeInvalidVariable, "define variables as '@name = value'", "invalid variable definition")
 	}
 	key := strings.TrimSpace(parts[0])
 	key = strings.TrimLeft(key, "@")
 	value := strings.TrimSpace(parts[1])
 	vars[key] = value
+	return key, nil
+}
+
+// handleScopedVariable records a variable declared after the first request. It applies to
+// the request at index and all following ones.
+func handleScopedVariable(line string, index int, pos internal.Position, scoped *[]internal.ScopedVariable) error {
+	vars := make(map[string]string, 1)
+	key, err := handleVariableDefinition(line, vars)
+	if err != nil {
+		return err
+	}
+	*scoped = append(*scoped, internal.ScopedVariable{Name: key, Value: vars[key], Request: index, Position: pos})
+	return nil
+}
+
+func handleFileReference(line string, dir string, request *internal.Request) error {
+	if request.BodyFile != nil {
+		return newSyntaxError(codeBodyConflict, "remove all but one file reference", "request body cannot reference more than one file")
+	}
+	if request.Body != "" {
+		return newSyntaxError(codeBodyConflict, "move the inline content into the referenced file or remove the file reference",
+			"request body cannot combine inline content and a file reference")
+	}
+	file, err := parseFileReference(line, dir)
+	if err != nil {
+		return err
+	}
+	request.BodyFile = file
+	return nil
+}
+
+func parseFileReference(line string, dir string) (*internal.BodyFile, error) {
+	raw := strings.HasPrefix(line, "<@")
+	path := strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(line, "<@"), "<"))
+	if path == "" {
+		return nil, newSyntaxError(codeMissingFilePath, "add the path of the file, e.g. '< ./body.json'", "missing file path").at(len(line) + 1)
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	return &internal.BodyFile{Path: path, Raw: raw}, nil
+}
+
+// handleResponseHandlerFile appends the script of the file referenced by line to the
+// response handler of the request.
+func handleResponseHandlerFile(line string, dir string, request *internal.Request) error {
+	path := strings.TrimSpace(strings.TrimPrefix(line, ">"))
+	abs := path
+	if !filepath.IsAbs(abs) {
+		abs = filepath.Join(dir, abs)
+	}
+	content, err := os.ReadFile(abs)
+	if err != nil {
+		return newSyntaxError(codeHandlerFileFailed, "check the path of the response handler file",
+			"failed to read response handler file '%s'", path).at(strings.Index(line, path) + 1)
+	}
+	for _, l := range strings.Split(string(content), "\n") {
+		appendScriptLine(request, strings.TrimRight(l, "\r"))
+	}
+	return nil
+}
+
+func handleOutputRedirect(line string, dir string, request *internal.Request) error {
+	overwrite := strings.HasPrefix(line, ">>!")
+	path := strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(line, ">>!"), ">>"))
+	if path == "" {
+		return newSyntaxError(codeMissingFilePath, "add the path of the output file, e.g. '>> ./response.json'", "missing file path").at(len(line) + 1)
+	}
+	if request.ResponseOutput != nil {
+		return newSyntaxError(codeDuplicateOutput, "remove all but one '>>' line", "response output already redirected")
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	request.ResponseOutput = &internal.ResponseOutput{Path: path, Overwrite: overwrite}
 	return nil
 }
 
@@ -157,41 +539,129 @@ func handleNewRequest(line string, requests *[]internal.Request, request *intern
 
 func handleRequestLine(line string, request *internal.Request, lineCounter int) error {
 	parts := strings.Fields(line)
-	if len(parts) == 1 && strings.HasPrefix(parts[0], "http") {
+	for i, part := range parts {
+		if strings.HasPrefix(part, "HTTP/") {
+			version, ok := parseHttpVersion(strings.Join(parts[i:], " "))
+			if !ok {
+				return newSyntaxError(codeUnsupportedVersion, "use HTTP/1.1, HTTP/2 or HTTP/2 (Prior Knowledge)",
+					"unsupported HTTP version").at(columnOf(line, part))
+			}
+			request.HttpVersion = version
+			parts = parts[:i]
+			break
+		}
+	}
+
+	if len(parts) == 1 && isBareTarget(parts[0]) {
 		request.Method = "GET"
 		request.Url = parts[0]
-	} else if len(parts) == 2 && isAllowedMethod(parts[0]) {
+	} else if len(parts) == 2 && parts[0] == graphQLMethod {
+		request.Method = "POST"
+		request.Url = parts[1]
+		request.GraphQL = &internal.GraphQL{}
+	} else if len(parts) == 2 && parts[0] == webSocketMethod {
+		request.Method = "GET"
+		request.Url = parts[1]
+		request.WebSocket = &internal.WebSocket{}
+	} else if len(parts) == 2 && parts[0] == grpcMethod {
+		request.Method = "POST"
+		request.Url = parts[1]
+		request.GRPC = &internal.GRPC{}
+		if err := checkGRPCTarget(line, request.Url); err != nil {
+			return err
+		}
+	} else if len(parts) == 2 && isMethod(parts[0]) {
 		request.Method = parts[0]
 		request.Url = parts[1]
+	} else if len(parts) == 2 {
+		return newSyntaxError(codeInvalidRequestLine, "use a method made of letters, digits and the characters !#$%&'*+-.^_`|~, e.g. GET",
+			"invalid request method '%s'", parts[0])
 	} else {
-		return fmt.Errorf("parsing error: invalid request at line %d", lineCounter)
+		return newSyntaxError(codeInvalidRequestLine, "use '<METHOD> <URL> [HTTP/<version>]'", "invalid request")
 	}
+	request.Line = lineCounter
 	return nil
 }
 
-func handleHeaderLine(line string, request *internal.Request, lineCounter int) error {
+// isBareTarget reports whether a request line without method is a request target: an
+// absolute URL, a relative URL like `/users` or a URL starting with a placeholder.
+func isBareTarget(target string) bool {
+	return strings.HasPrefix(target, "http") || internal.IsRelativeURL(target) || strings.HasPrefix(target, "{{")
+}
+
+// handleUrlContinuation appends a query continuation line (starting with '?' or '&')
+// to the URL of the request line. The last continuation line may carry the HTTP version.
+func handleUrlContinuation(line string, request *internal.Request) error {
+	if request.HttpVersion != "" {
+		return newSyntaxError(codeInvalidContinuation, "move the HTTP version to the last line of the URL",
+			"URL continuation after HTTP version")
+	}
+
+	parts := strings.Fields(line)
+	if len(parts) > 1 {
+		version, ok := parseHttpVersion(strings.Join(parts[1:], " "))
+		if !ok {
+			return newSyntaxError(codeInvalidContinuation, "only the HTTP version may follow the query parameter",
+				"invalid URL continuation").at(columnOf(line, parts[1]))
+		}
+		request.HttpVersion = version
+	}
+	request.Url += parts[0]
+	return nil
+}
+
+// parseHttpVersion normalizes the protocol version of a request line.
+// IntelliJ's "HTTP/2 (Prior Knowledge)" is accepted as an alias of HTTP/2.
+func parseHttpVersion(version string) (string, bool) {
+	switch version {
+	case "HTTP/1.1":
+		return internal.HTTP11, true
+	case "HTTP/2", "HTTP/2.0", "HTTP/2 (Prior Knowledge)":
+		return internal.HTTP2, true
+	default:
+		return "", false
+	}
+}
+
+func handleHeaderLine(line string, request *internal.Request) error {
 	if !strings.Contains(line, ":") && line != "" {
-		return fmt.Errorf("parsing error: expected blank line between headers and body at line %d", lineCounter)
+		return newSyntaxError(codeMissingSeparator, "add a blank line before the body, or separate header name and value with ':'",
+			"expected blank line between headers and body")
 	}
 	parts := strings.SplitN(line, ":", 2)
 	if len(parts) != 2 {
-		return fmt.Errorf("parsing error: invalid header at line %d", lineCounter)
+		return newSyntaxError(codeInvalidHeader, "separate header name and value with ':'", "invalid header")
 	}
-	request.Headers[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	request.Headers[headerName(line)] = strings.TrimSpace(parts[1])
 	return nil
 }
 
+func headerName(line string) string {
+	name, _, _ := strings.Cut(line, ":")
+	return strings.TrimSpace(name)
+}
+
 func isEmptyLine(line string) bool {
 	return line == "\n" || line == ""
 }
 
 func newRequest() internal.Request {
 	return internal.Request{
-		Headers: map[string]string{},
+		Headers:     map[string]string{},
+		HeaderLines: map[string]int{},
 	}
 }
 
 func appendAndReset(requests *[]internal.Request, request *internal.Request) {
+	request.Body = trimTrailingSeparator(request.Body)
+	if request.GraphQL != nil {
+		request.GraphQL = newGraphQL(request.Body)
+		request.Body = ""
+	}
+	if request.WebSocket != nil {
+		request.WebSocket = newWebSocket(request.Body)
+		request.Body = ""
+	}
 	if request.Method != "" && request.Url != "" {
 		*requests = append(*requests, *request)
 	}
@@ -209,16 +679,37 @@ func getName(line string, counter int) string {
 	return name
 }
 
-func isAllowedMethod(method string) bool {
+// isMethod reports whether method is a token as defined by RFC 9110, section 5.6.2.
+func isMethod(method string) bool {
 	if method == "" {
 		return false
 	}
 
-	for _, m := range methods {
-		if m == method {
-			return true
+	for _, c := range method {
+		if !isTokenChar(c) {
+			return false
 		}
 	}
 
-	return false
+	return true
+}
+
+func isTokenChar(c rune) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("!#$%&'*+-.^_`|~", c)
+}
+
+// checkStandardMethod returns a warning if method is not one of the standardMethods.
+// Methods are case-sensitive, so lowercase variants of standard methods are reported as well.
+func checkStandardMethod(line string, method string) error {
+	if slices.Contains(standardMethods, method) {
+		return nil
+	}
+
+	fix := "check the spelling of the method, or remove the strict mode if the server expects it"
+	if upper := strings.ToUpper(method); slices.Contains(standardMethods, upper) {
+		fix = "methods are case-sensitive, use " + upper
+	}
+	err := newSyntaxError(codeNonStandardMethod, fix, "non-standard request method '%s'", method)
+	err.severity = internal.SeverityWarning
+	return err.at(columnOf(line, method))
 }
@@ -1,7 +1,10 @@
 package parser
 
 import (
+	"github.com/fdrolshagen/jetter/internal"
 	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -88,6 +91,25 @@ func TestParseHttp_ShouldDefaultToGET(t *testing.T) {
 	assert.Equal(t, "GET", req.Method)
 }
 
+func TestParseHttp_ShouldAcceptRelativeAndPlaceholderUrlsWithoutMethod(t *testing.T) {
+	content := `
+		### Relative
+		/users?page=1
+
+		### Placeholder
+		{{host}}/users
+		`
+
+	c, err := ParseHttp(strings.NewReader(content))
+
+	assert.Nil(t, err)
+	assert.Len(t, c.Requests, 2)
+	assert.Equal(t, "GET", c.Requests[0].Method)
+	assert.Equal(t, "/users?page=1", c.Requests[0].Url)
+	assert.Equal(t, "GET", c.Requests[1].Method)
+	assert.Equal(t, "{{host}}/users", c.Requests[1].Url)
+}
+
 func TestParseHttp_ShouldParseError(t *testing.T) {
 	content := `
 		### request
@@ -153,6 +175,109 @@ func TestParseHttp_ShouldErrorOnParseGlobalVariable(t *testing.T) {
 	assert.NotNil(t, err)
 }
 
+func TestParseHttp_ShouldRecordScopedVariables(t *testing.T) {
+	content := strings.TrimSpace(`
+		@ID = 1
+
+		### First
+		GET http://localhost:8081/users/{{ID}}
+
+		### Second
+		@ID = 2
+		# @name Second
+		@NAME = {{ID}}-admin
+		GET http://localhost:8081/users/{{ID}}
+
+		### Third
+		GET http://localhost:8081/users/{{ID}}
+		`)
+
+	c, err := ParseHttp(strings.NewReader(content))
+
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"ID": "1"}, c.Variables)
+	assert.Equal(t, []internal.ScopedVariable{
+		{Name: "ID", Value: "2", Request: 1, Position: internal.Position{Line: 7}},
+		{Name: "NAME", Value: "{{ID}}-admin", Request: 1, Position: internal.Position{Line: 9}},
+	}, c.ScopedVariables)
+	assert.Len(t, c.Requests, 3)
+	assert.Equal(t, "Second", c.Requests[1].Name)
+	assert.Empty(t, c.Requests[1].Headers)
+}
+
+func TestParseHttp_ScopedVariablesShouldShadowInOrder(t *testing.T) {
+	content := strings.TrimSpace(`
+		@ID = 1
+
+		### First
+		@ID = 2
+		@ID = 3
+		GET http://localhost:8081/users/{{ID}}
+
+		### Second
+		GET http://localhost:8081/users/{{ID}}
+
+		### Third
+		@ID = 4
+		run #First
+		@ID = 5
+		run #Second
+		`)
+
+	c, err := ParseHttp(strings.NewReader(content))
+
+	assert.Nil(t, err)
+	assert.Len(t, c.Requests, 4)
+	var ids []string
+	for i := range c.Requests {
+		ids = append(ids, internal.ScopeAt(c.ScopedVariables, i)["ID"])
+	}
+	assert.Equal(t, []string{"3", "3", "4", "5"}, ids)
+	assert.Equal(t, "1", c.Variables["ID"])
+}
+
+func TestParseHttp_ShouldErrorOnInvalidScopedVariable(t *testing.T) {
+	_, err := ParseHttp(strings.NewReader("###\n@ID 2\nGET http://localhost:8081/users"))
+
+	diagnostics := requireDiagnostics(t, err)
+	assert.Equal(t, codeInvalidVariable, diagnostics[0].Code)
+	assert.Equal(t, 2, diagnostics[0].Line)
+}
+
+func TestParseHttpFile_ScopedVariablesOfImportsApplyToTheirRequests(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"auth.http": `
+			### Login
+			@USER = admin
+			POST http://localhost:8081/login?user={{USER}}
+
+			### Logout
+			@USER = guest
+			POST http://localhost:8081/logout?user={{USER}}
+			`,
+		"scenario.http": `
+			import ./auth.http
+
+			### Run
+			@USER = other
+			run #Login
+			run #Logout (@USER=root)
+
+			### Get Users
+			GET http://localhost:8081/users
+			`,
+	})
+
+	c, err := ParseHttpFile(filepath.Join(dir, "scenario.http"))
+
+	assert.Nil(t, err)
+	assert.Len(t, c.Requests, 3)
+	assert.Equal(t, map[string]string{"USER": "admin"}, c.Requests[0].Variables)
+	assert.Equal(t, map[string]string{"USER": "root"}, c.Requests[1].Variables)
+	assert.Nil(t, c.Requests[2].Variables)
+	assert.Equal(t, "other", internal.ScopeAt(c.ScopedVariables, 2)["USER"])
+}
+
 func TestParseHttp_ShouldErrorOnMissingHeaderBodySeparation(t *testing.T) {
 	content := strings.TrimSpace(`
 		### Create User
@@ -316,7 +441,7 @@ func TestParseHttp_ShouldIgnoreFileInAndOut(t *testing.T) {
 		POST http://localhost:8081/commented
 		Content-Type: application/json
 
-		> file.txt
+		> testdata/handlers/check.js
 		`)
 
 	c, err := ParseHttp(strings.NewReader(content))
@@ -324,7 +449,7 @@ func TestParseHttp_ShouldIgnoreFileInAndOut(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Len(t, c.Requests, 1)
 	body := c.Requests[0].Body
-	assert.NotContains(t, body, "file.txt")
+	assert.NotContains(t, body, "check.js")
 
 	content = strings.TrimSpace(`
 		### Request With File Out
@@ -341,3 +466,389 @@ func TestParseHttp_ShouldIgnoreFileInAndOut(t *testing.T) {
 	body = c.Requests[0].Body
 	assert.NotContains(t, body, "file.txt")
 }
+
+func TestParseHttp_ShouldCaptureSingleLineScript(t *testing.T) {
+	content := strings.TrimSpace(`
+		### Login
+		POST http://localhost:8081/login
+
+		> {% client.global.set("token", response.body.token); %}
+		`)
+
+	c, err := ParseHttp(strings.NewReader(content))
+
+	assert.Nil(t, err)
+	assert.Len(t, c.Requests, 1)
+	assert.Equal(t, "client.global.set(\"token\", response.body.token);\n", c.Requests[0].ResponseHandler)
+}
+
+func TestParseHttp_ShouldCaptureScriptBlock(t *testing.T) {
+	content := strings.TrimSpace(`
+		### Login
+		POST http://localhost:8081/login
+		Content-Type: application/json
+
+		{"user": "foo"}
+
+		> {%
+			client.test("ok", function() {
+				client.assert(response.status === 200);
+			});
+		%}
+
+		### Another Request
+		GET http://localhost:8081/users
+		`)
+
+	c, err := ParseHttp(strings.NewReader(content))
+
+	assert.Nil(t, err)
+	assert.Len(t, c.Requests, 2)
+	assert.Equal(t, "client.test(\"ok\", function() {\nclient.assert(response.status === 200);\n});\n", c.Requests[0].ResponseHandler)
+	assert.NotContains(t, c.Requests[0].Body, "client")
+	assert.Empty(t, c.Requests[1].ResponseHandler)
+}
+
+func TestParseHttp_ShouldLoadResponseHandlerFile(t *testing.T) {
+	content := strings.TrimSpace(`
+		### Create
+		POST http://localhost:8081/users
+
+		{"name": "foo"}
+
+		> testdata/handlers/check.js
+		`)
+
+	c, err := ParseHttp(strings.NewReader(content))
+
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"name\": \"foo\"}", strings.TrimSpace(c.Requests[0].Body))
+	assert.Equal(t, "client.test(\"created\", function() {\n  client.assert(response.status === 201);\n});\n", c.Requests[0].ResponseHandler)
+}
+
+func TestParseHttp_ShouldErrorOnMissingResponseHandlerFile(t *testing.T) {
+	content := "###\nPOST http://localhost:8081/users\n\n> ./missing.js\n"
+
+	_, err := ParseHttp(strings.NewReader(content))
+
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "failed to read response handler file './missing.js'")
+}
+
+func TestParseHttp_ShouldParseFileBody(t *testing.T) {
+	content := strings.TrimSpace(`
+		### Upload
+		POST http://localhost:8081/users
+		Content-Type: application/json
+
+		< ./fixtures/user.json
+
+		### Upload Raw
+		POST http://localhost:8081/users
+		Content-Type: application/json
+
+		<@ ./fixtures/user.json
+		`)
+
+	c, err := ParseHttp(strings.NewReader(content))
+
+	assert.Nil(t, err)
+	assert.Len(t, c.Requests, 2)
+	assert.Empty(t, c.Requests[0].Body)
+	assert.Equal(t, &internal.BodyFile{Path: filepath.Join("fixtures", "user.json")}, c.Requests[0].BodyFile)
+	assert.Equal(t, &internal.BodyFile{Path: filepath.Join("fixtures", "user.json"), Raw: true}, c.Requests[1].BodyFile)
+}
+
+func TestParseHttp_ShouldParseXmlBody(t *testing.T) {
+	content := "###\nPOST http://localhost:8081/users\nContent-Type: application/xml\n\n<?xml version=\"1.0\"?>\n<root>\n  <name>foo</name>\n</root>\n"
+
+	c, err := ParseHttp(strings.NewReader(content))
+
+	assert.Nil(t, err)
+	assert.Len(t, c.Requests, 1)
+	assert.Nil(t, c.Requests[0].BodyFile)
+	assert.Equal(t, "<?xml version=\"1.0\"?>\n<root>\n  <name>foo</name>\n</root>", c.Requests[0].Body)
+}
+
+func TestParseHttpFile_ShouldResolveFileBodyRelativeToHttpFile(t *testing.T) {
+	dir := t.TempDir()
+	httpFile := filepath.Join(dir, "scenario.http")
+	err := os.WriteFile(httpFile, []byte("###\nPOST http://localhost/users\n\n< body.json\n"), 0644)
+	assert.NoError(t, err)
+
+	c, err := ParseHttpFile(httpFile)
+
+	assert.Nil(t, err)
+	assert.Len(t, c.Requests, 1)
+	assert.Equal(t, filepath.Join(dir, "body.json"), c.Requests[0].BodyFile.Path)
+}
+
+func TestParseHttp_ShouldErrorOnInlineBodyAndFileReference(t *testing.T) {
+	content := strings.TrimSpace(`
+		###
+		POST http://localhost:8081/users
+
+		{"name": "foo"}
+		< ./user.json
+		`)
+
+	_, err := ParseHttp(strings.NewReader(content))
+
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "cannot combine inline content and a file reference")
+}
+
+func TestParseHttp_ShouldParseOutputRedirect(t *testing.T) {
+	content := strings.TrimSpace(`
+		### Append
+		GET http://localhost:8081/users
+
+		>> ./out/users.json
+
+		### Overwrite
+		POST http://localhost:8081/users
+		Content-Type: application/json
+
+		{"name": "foo"}
+
+		> {% client.global.set("a", "b"); %}
+		>>! ./out/user.json
+		`)
+
+	c, err := ParseHttp(strings.NewReader(content))
+
+	assert.Nil(t, err)
+	assert.Len(t, c.Requests, 2)
+	assert.Equal(t, &internal.ResponseOutput{Path: filepath.Join("out", "users.json")}, c.Requests[0].ResponseOutput)
+	assert.Equal(t, &internal.ResponseOutput{Path: filepath.Join("out", "user.json"), Overwrite: true}, c.Requests[1].ResponseOutput)
+	assert.NotContains(t, c.Requests[1].Body, "out")
+	assert.NotEmpty(t, c.Requests[1].ResponseHandler)
+}
+
+func TestParseHttp_ShouldParseHttpVersion(t *testing.T) {
+	content := strings.TrimSpace(`
+		### HTTP/1.1
+		GET https://localhost:8081/users HTTP/1.1
+
+		### HTTP/2
+		GET https://localhost:8081/users HTTP/2
+
+		### HTTP/2 Prior Knowledge
+		GET http://localhost:8081/users HTTP/2 (Prior Knowledge)
+
+		### Without Method
+		https://localhost:8081/users HTTP/1.1
+
+		### Without Version
+		GET https://localhost:8081/users
+		`)
+
+	c, err := ParseHttp(strings.NewReader(content))
+
+	assert.Nil(t, err)
+	assert.Len(t, c.Requests, 5)
+	assert.Equal(t, internal.HTTP11, c.Requests[0].HttpVersion)
+	assert.Equal(t, internal.HTTP2, c.Requests[1].HttpVersion)
+	assert.Equal(t, internal.HTTP2, c.Requests[2].HttpVersion)
+	assert.Equal(t, "http://localhost:8081/users", c.Requests[2].Url)
+	assert.Equal(t, "GET", c.Requests[3].Method)
+	assert.Equal(t, internal.HTTP11, c.Requests[3].HttpVersion)
+	assert.Empty(t, c.Requests[4].HttpVersion)
+}
+
+func TestParseHttp_ShouldErrorOnUnsupportedHttpVersion(t *testing.T) {
+	content := strings.TrimSpace(`
+		###
+		GET https://localhost:8081/users HTTP/3
+		`)
+
+	_, err := ParseHttp(strings.NewReader(content))
+
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "unsupported HTTP version at line 2")
+}
+
+func TestParseHttp_ShouldJoinUrlContinuationLines(t *testing.T) {
+	content := strings.TrimSpace(`
+		### Search
+		GET http://localhost:8081/users
+		    ?name=foo
+		    &page=1
+		    &size=20 HTTP/1.1
+		Accept: application/json
+
+		### Next
+		GET http://localhost:8081/users
+		`)
+
+	c, err := ParseHttp(strings.NewReader(content))
+
+	assert.Nil(t, err)
+	assert.Len(t, c.Requests, 2)
+	assert.Equal(t, "http://localhost:8081/users?name=foo&page=1&size=20", c.Requests[0].Url)
+	assert.Equal(t, internal.HTTP11, c.Requests[0].HttpVersion)
+	assert.Equal(t, "application/json", c.Requests[0].Headers["Accept"])
+	assert.Equal(t, 2, c.Requests[0].Line)
+	assert.Equal(t, 9, c.Requests[1].Line)
+}
+
+func TestParseHttp_UrlContinuationErrorsReportCorrectLine(t *testing.T) {
+	content := strings.TrimSpace(`
+		### Search
+		GET http://localhost:8081/users
+		    ?name=foo
+		    &page=1
+		Accept application/json
+		`)
+
+	_, err := ParseHttp(strings.NewReader(content))
+
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "at line 5")
+}
+
+func TestParseHttp_ShouldErrorOnUrlContinuationAfterHeader(t *testing.T) {
+	content := strings.TrimSpace(`
+		### Search
+		GET http://localhost:8081/users
+		Accept: application/json
+		    ?name=foo
+		`)
+
+	_, err := ParseHttp(strings.NewReader(content))
+
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "at line 4")
+}
+
+func TestParseHttpFile_ShouldPreserveBodyFormatting(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "bodies", "*.http"))
+	assert.Nil(t, err)
+	assert.NotEmpty(t, files)
+
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".http")
+		t.Run(name, func(t *testing.T) {
+			golden, err := os.ReadFile(strings.TrimSuffix(file, ".http") + ".golden")
+			assert.Nil(t, err)
+
+			c, err := ParseHttpFile(file)
+
+			assert.Nil(t, err)
+			assert.NotEmpty(t, c.Requests)
+			assert.Equal(t, string(golden), c.Requests[0].Body)
+		})
+	}
+}
+
+func TestParseHttp_ShouldAcceptAnyTokenAsMethod(t *testing.T) {
+	content := strings.TrimSpace(`
+		###
+		TRACE http://localhost:8081/users
+
+		###
+		PROPFIND http://localhost:8081/files
+		Depth: 1
+
+		###
+		QUERY http://localhost:8081/users
+		Content-Type: application/json
+
+		{"name": "jetter"}
+
+		###
+		get http://localhost:8081/users
+		`)
+
+	c, err := ParseHttp(strings.NewReader(content))
+
+	assert.Nil(t, err)
+	assert.Empty(t, c.Warnings)
+	assert.Len(t, c.Requests, 4)
+	assert.Equal(t, "TRACE", c.Requests[0].Method)
+	assert.Equal(t, "PROPFIND", c.Requests[1].Method)
+	assert.Equal(t, "QUERY", c.Requests[2].Method)
+	assert.Equal(t, `{"name": "jetter"}`, strings.TrimSpace(c.Requests[2].Body))
+	assert.Equal(t, "get", c.Requests[3].Method)
+}
+
+func TestParseHttp_ShouldErrorOnInvalidMethod(t *testing.T) {
+	for _, method := range []string{"GET:", "GE(T", "{{METHOD}}"} {
+		_, err := ParseHttp(strings.NewReader("###\n" + method + " http://localhost:8081/users"))
+
+		diagnostics := requireDiagnostics(t, err)
+		assert.Equal(t, "invalid request method '"+method+"'", diagnostics[0].Message)
+	}
+}
+
+func TestParseHttp_StrictModeShouldWarnOnNonStandardMethods(t *testing.T) {
+	content := strings.TrimSpace(`
+		#@jetter strict
+
+		###
+		MKCOL http://localhost:8081/files/new
+
+		###
+		PURGE http://localhost:8081/cache
+
+		###
+		get http://localhost:8081/users
+		`)
+
+	c, err := ParseHttp(strings.NewReader(content))
+
+	assert.Nil(t, err)
+	assert.True(t, c.Config.Strict)
+	assert.Len(t, c.Requests, 3)
+	assert.Equal(t, []internal.Diagnostic{
+		{
+			Severity: internal.SeverityWarning,
+			Line:     7,
+			Column:   3,
+			Code:     codeNonStandardMethod,
+			Message:  "non-standard request method 'PURGE'",
+			Fix:      "check the spelling of the method, or remove the strict mode if the server expects it",
+		},
+		{
+			Severity: internal.SeverityWarning,
+			Line:     10,
+			Column:   3,
+			Code:     codeNonStandardMethod,
+			Message:  "non-standard request method 'get'",
+			Fix:      "methods are case-sensitive, use GET",
+		},
+	}, c.Warnings)
+}
+
+func TestParseHttpFileWithOptions_StrictModeShouldApplyToImports(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"common.http": `
+			### Purge
+			PURGE http://localhost:8081/cache
+			`,
+		"scenario.http": `
+			import ./common.http
+			run #Purge
+			`,
+	})
+
+	c, err := ParseHttpFile(filepath.Join(dir, "scenario.http"))
+	assert.Nil(t, err)
+	assert.Empty(t, c.Warnings)
+
+	c, err = ParseHttpFileWithOptions(filepath.Join(dir, "scenario.http"), Options{Strict: true})
+	assert.Nil(t, err)
+	assert.Len(t, c.Warnings, 1)
+	assert.Equal(t, codeNonStandardMethod, c.Warnings[0].Code)
+	assert.Equal(t, filepath.Join(dir, "common.http"), c.Warnings[0].File)
+}
+
+func TestParseHttp_ShouldRecordHeaderAndBodyLines(t *testing.T) {
+	content := "###\nPOST http://localhost/users\nContent-Type: application/json\nX-Tenant: foo\n\n\n{\"name\": \"foo\"}\n"
+
+	c, err := ParseHttp(strings.NewReader(content))
+
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int{"Content-Type": 3, "X-Tenant": 4}, c.Requests[0].HeaderLines)
+	assert.Equal(t, 7, c.Requests[0].BodyLine)
+}
@@ -0,0 +1,264 @@
+package parser
+
+import (
+	"errors"
+	"github.com/fdrolshagen/jetter/internal"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// importer resolves `import` and `run` statements. It keeps track of the chain of
+// files currently being parsed to detect cycles, and caches parsed files so that
+// a file imported by several others is only read once.
+type importer struct {
+	stack   []string
+	cache   map[string]internal.Collection
+	options Options
+}
+
+func newImporter() *importer {
+	return &importer{cache: make(map[string]internal.Collection)}
+}
+
+// parseFile parses the .http file at path, including everything it imports.
+func (imp *importer) parseFile(path string) (internal.Collection, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return internal.Collection{}, err
+	}
+
+	for i, p := range imp.stack {
+		if p == abs {
+			chain := make([]string, 0, len(imp.stack)-i+1)
+			for _, f := range append(imp.stack[i:], abs) {
+				chain = append(chain, filepath.Base(f))
+			}
+			return internal.Collection{}, newSyntaxError(codeImportFailed, "remove one of the imports or run statements of the cycle",
+				"import cycle: %s", strings.Join(chain, " -> "))
+		}
+	}
+	if c, ok := imp.cache[abs]; ok {
+		return c, nil
+	}
+
+	file, err := os.Open(abs)
+	if err != nil {
+		return internal.Collection{}, err
+	}
+	defer file.Close()
+
+	imp.stack = append(imp.stack, abs)
+	c, err := parseHttp(file, path, filepath.Dir(abs), imp)
+	imp.stack = imp.stack[:len(imp.stack)-1]
+	if err != nil {
+		return internal.Collection{}, err
+	}
+
+	imp.cache[abs] = c
+	return c, nil
+}
+
+// runStatement is a parsed `run #name (@var=value)` or `run ./file.http` line.
+type runStatement struct {
+	name      string
+	file      string
+	variables map[string]string
+}
+
+func isImport(line string) bool {
+	return isStatement(line, "import")
+}
+
+func isRun(line string) bool {
+	return isStatement(line, "run")
+}
+
+// isStatement reports whether line is the statement with the given keyword, which is
+// reserved and never taken as a request method.
+func isStatement(line, keyword string) bool {
+	rest, ok := strings.CutPrefix(line, keyword)
+	return ok && (rest == "" || rest[0] == ' ' || rest[0] == '\t')
+}
+
+// handleImport parses the imported file and makes its requests available to run statements.
+// Variables of the imported file are added unless they are already defined.
+func handleImport(line string, dir string, imp *importer, imported *internal.Collection) error {
+	path := strings.TrimSpace(strings.TrimPrefix(line, "import"))
+	if path == "" {
+		return newSyntaxError(codeInvalidImport, "add the path of the file, e.g. 'import ./auth.http'",
+			"missing file path for import").at(len(line) + 1)
+	}
+
+	c, err := imp.parseFile(resolvePath(dir, path))
+	if err != nil {
+		return importError("import", line, path, err)
+	}
+
+	imported.Requests = append(imported.Requests, scopedRequests(c)...)
+	mergeVariables(imported.Variables, c.Variables)
+	mergeVariables(imported.VariablePositions, c.VariablePositions)
+	imported.Warnings = append(imported.Warnings, c.Warnings...)
+	return nil
+}
+
+// handleRun resolves a run statement into the requests it executes. Named requests
+// are looked up in the requests defined so far and in the imported requests.
+func handleRun(line string, dir string, imp *importer, requests []internal.Request, imported *internal.Collection) ([]internal.Request, error) {
+	stmt, err := parseRunStatement(line)
+	if err != nil {
+		return nil, err
+	}
+
+	var resolved []internal.Request
+	if stmt.file != "" {
+		c, err := imp.parseFile(resolvePath(dir, stmt.file))
+		if err != nil {
+			return nil, importError("run", line, stmt.file, err)
+		}
+		resolved = scopedRequests(c)
+		mergeVariables(imported.Variables, c.Variables)
+		mergeVariables(imported.VariablePositions, c.VariablePositions)
+		imported.Warnings = append(imported.Warnings, c.Warnings...)
+	} else {
+		r, ok := findRequest(stmt.name, requests, imported.Requests)
+		if !ok {
+			return nil, newSyntaxError(codeUnknownRequest, "define the request with '### "+stmt.name+"' or '# @name "+stmt.name+"' before the run statement, or import its file",
+				"unknown request '#%s'", stmt.name).at(columnOf(line, "#"+stmt.name))
+		}
+		resolved = []internal.Request{r}
+	}
+
+	result := make([]internal.Request, 0, len(resolved))
+	for _, r := range resolved {
+		result = append(result, withVariables(r, stmt.variables))
+	}
+	return result, nil
+}
+
+// scopedRequests returns the requests of c with the scoped variables that apply to them added
+// to their variables, as scoped variables do not carry over to the importing file. Variables
+// already present on a request take precedence.
+func scopedRequests(c internal.Collection) []internal.Request {
+	if len(c.ScopedVariables) == 0 {
+		return c.Requests
+	}
+	result := make([]internal.Request, len(c.Requests))
+	for i, r := range c.Requests {
+		vars := internal.ScopeAt(c.ScopedVariables, i)
+		for k, v := range r.Variables {
+			vars[k] = v
+		}
+		r.Variables = vars
+		result[i] = r
+	}
+	return result
+}
+
+func parseRunStatement(line string) (runStatement, error) {
+	rest := strings.TrimSpace(strings.TrimPrefix(line, "run"))
+	target, overrides, hasOverrides := strings.Cut(rest, "(")
+	target = strings.TrimSpace(target)
+
+	var stmt runStatement
+	if strings.HasPrefix(target, "#") {
+		stmt.name = strings.TrimSpace(strings.TrimPrefix(target, "#"))
+	} else {
+		stmt.file = target
+	}
+	if stmt.name == "" && stmt.file == "" {
+		return stmt, newSyntaxError(codeInvalidRun, "add a request name or file, e.g. 'run #login' or 'run ./auth.http'",
+			"missing target for run").at(len(line) + 1)
+	}
+
+	if !hasOverrides {
+		return stmt, nil
+	}
+	overrides = strings.TrimSpace(overrides)
+	if !strings.HasSuffix(overrides, ")") {
+		return stmt, newSyntaxError(codeInvalidRun, "close the variable overrides with ')'", "missing ')' in run").at(len(line) + 1)
+	}
+
+	stmt.variables = make(map[string]string)
+	for _, o := range strings.Split(strings.TrimSuffix(overrides, ")"), ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(o), "=")
+		key = strings.TrimSpace(key)
+		if !ok || !strings.HasPrefix(key, "@") || len(key) == 1 {
+			return stmt, newSyntaxError(codeInvalidRun, "write overrides as '(@name=value, ...)'",
+				"invalid variable override '%s' in run", strings.TrimSpace(o)).at(columnOf(line, strings.TrimSpace(o)))
+		}
+		stmt.variables[strings.TrimPrefix(key, "@")] = strings.TrimSpace(value)
+	}
+	return stmt, nil
+}
+
+// importError reports that the file at path could not be imported or run. Diagnostics
+// of the file itself are kept, so that they are reported with their own positions.
+func importError(verb, line, path string, err error) error {
+	column := columnOf(line, path)
+	var nested internal.Diagnostics
+	if errors.As(err, &nested) {
+		e := newSyntaxError(codeImportFailed, "fix the problems reported for "+path, "failed to %s '%s'", verb, path).at(column)
+		e.nested = nested
+		return e
+	}
+	var se *syntaxError
+	if errors.As(err, &se) {
+		return newSyntaxError(se.code, se.fix, "failed to %s '%s': %s", verb, path, se.message).at(column)
+	}
+	return newSyntaxError(codeImportFailed, "check the path of the file, it is resolved relative to the importing file",
+		"failed to %s '%s': %v", verb, path, err).at(column)
+}
+
+func findRequest(name string, sources ...[]internal.Request) (internal.Request, bool) {
+	for _, requests := range sources {
+		for _, r := range requests {
+			if r.Name == name {
+				return r, true
+			}
+		}
+	}
+	return internal.Request{}, false
+}
+
+// withVariables returns a copy of r with the given variable overrides,
+// which take precedence over overrides already present on r.
+func withVariables(r internal.Request, overrides map[string]string) internal.Request {
+	if len(overrides) == 0 {
+		return r
+	}
+	vars := make(map[string]string, len(r.Variables)+len(overrides))
+	for k, v := range r.Variables {
+		vars[k] = v
+	}
+	for k, v := range overrides {
+		vars[k] = v
+	}
+	r.Variables = vars
+	return r
+}
+
+func mergeVariables[V any](dst, src map[string]V) {
+	for k, v := range src {
+		if _, exists := dst[k]; !exists {
+			dst[k] = v
+		}
+	}
+}
+
+func resolvePath(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// misplacedStatementError reports an import or run statement within a request.
+func misplacedStatementError(keyword string) error {
+	if keyword == "import" {
+		return newSyntaxError(codeMisplacedStatement, "move the import to the top of the file, before the first '###'",
+			"misplaced import statement")
+	}
+	return newSyntaxError(codeMisplacedStatement, "start a new request with '###' before the run statement",
+		"misplaced run statement")
+}
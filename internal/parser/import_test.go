@@ -0,0 +1,147 @@
+package parser
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, os.WriteFile(path, []byte(strings.TrimSpace(content)+"\n"), 0644))
+	}
+	return dir
+}
+
+func TestParseHttpFile_ShouldRunImportedRequests(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"shared/auth.http": `
+			@host = http://auth
+
+			### Login
+			POST {{host}}/login
+
+			< ./login.json
+
+			### Logout
+			POST {{host}}/logout
+			`,
+		"scenario.http": `
+			import ./shared/auth.http
+			@host = http://api
+
+			### Login first
+			run #Login (@user=alice, @password = secret)
+
+			### Get Users
+			GET {{host}}/users
+
+			###
+			run #Logout
+			run #Get Users
+			`,
+	})
+
+	c, err := ParseHttpFile(filepath.Join(dir, "scenario.http"))
+
+	assert.Nil(t, err)
+	assert.Len(t, c.Requests, 4)
+	assert.Equal(t, []string{"Login", "Get Users", "Logout", "Get Users"}, []string{
+		c.Requests[0].Name, c.Requests[1].Name, c.Requests[2].Name, c.Requests[3].Name,
+	})
+	assert.Equal(t, map[string]string{"user": "alice", "password": "secret"}, c.Requests[0].Variables)
+	assert.Equal(t, filepath.Join(dir, "shared", "login.json"), c.Requests[0].BodyFile.Path)
+	assert.Nil(t, c.Requests[2].Variables)
+	assert.Equal(t, "http://api", c.Variables["host"])
+}
+
+func TestParseHttpFile_ShouldRunFile(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"setup.http": `
+			@token = abc
+
+			### Create
+			POST http://localhost/users
+
+			### Verify
+			GET http://localhost/users
+			`,
+		"scenario.http": `
+			run ./setup.http (@id=1)
+
+			### Delete
+			DELETE http://localhost/users/{{id}}
+			`,
+	})
+
+	c, err := ParseHttpFile(filepath.Join(dir, "scenario.http"))
+
+	assert.Nil(t, err)
+	assert.Len(t, c.Requests, 3)
+	assert.Equal(t, "Create", c.Requests[0].Name)
+	assert.Equal(t, map[string]string{"id": "1"}, c.Requests[0].Variables)
+	assert.Equal(t, map[string]string{"id": "1"}, c.Requests[1].Variables)
+	assert.Equal(t, "Delete", c.Requests[2].Name)
+	assert.Equal(t, "abc", c.Variables["token"])
+}
+
+func TestParseHttpFile_ShouldDetectImportCycles(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"a.http": "import ./b.http",
+		"b.http": "import ./c.http",
+		"c.http": "import ./a.http",
+	})
+
+	_, err := ParseHttpFile(filepath.Join(dir, "a.http"))
+
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "import cycle: a.http -> b.http -> c.http -> a.http")
+}
+
+func TestParseHttpFile_ShouldAllowDiamondImports(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"common.http": "### Ping\nGET http://localhost/ping",
+		"a.http":      "import ./common.http\nrun #Ping",
+		"b.http":      "import ./common.http\nrun #Ping",
+		"main.http":   "run ./a.http\nrun ./b.http",
+	})
+
+	c, err := ParseHttpFile(filepath.Join(dir, "main.http"))
+
+	assert.Nil(t, err)
+	assert.Len(t, c.Requests, 2)
+}
+
+func TestParseHttp_RunErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		err     string
+		line    int
+	}{
+		{"unknown request", "run #Missing", "unknown request '#Missing'", 1},
+		{"missing target", "###\nrun ", "missing target for run", 2},
+		{"missing file", "run ./does-not-exist.http", "failed to run './does-not-exist.http'", 1},
+		{"missing import", "import ./does-not-exist.http", "failed to import './does-not-exist.http'", 1},
+		{"invalid override", "### Ping\nGET http://localhost\n\n###\nrun #Ping (user=alice)", "invalid variable override 'user=alice' in run", 5},
+		{"unclosed override", "### Ping\nGET http://localhost\n\n###\nrun #Ping (@user=alice", "missing ')' in run", 5},
+		{"import in request", "###\nimport ./other.http", "misplaced import statement", 2},
+		{"import after request line", "###\nGET http://localhost\nimport ./other.http", "misplaced import statement", 3},
+		{"run after request line", "### Ping\nGET http://localhost\n\n###\nGET http://localhost\nrun #Ping", "misplaced run statement", 6},
+		{"request after run", "### Ping\nGET http://localhost\n\n###\nrun #Ping\nGET http://localhost", "expected run statement or new request", 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseHttp(strings.NewReader(tt.content))
+			diagnostics := requireDiagnostics(t, err)
+			assert.Contains(t, diagnostics[0].Message, tt.err)
+			assert.Equal(t, tt.line, diagnostics[0].Line)
+		})
+	}
+}
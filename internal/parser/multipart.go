@@ -0,0 +1,135 @@
+package parser
+
+import (
+	"github.com/fdrolshagen/jetter/internal"
+	"mime"
+	"mime/multipart"
+	"strings"
+)
+
+// multipartParser builds the parts of a multipart/form-data body line by line.
+type multipartParser struct {
+	dir       string
+	delimiter string
+	body      *internal.MultipartBody
+	part      *internal.MultipartPart
+	partLine  int
+	inHeaders bool
+	content   []string
+}
+
+// isMultipartRequest reports whether the Content-Type header of the request is multipart/form-data.
+func isMultipartRequest(request *internal.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(headerValue(request.Headers, "Content-Type"))
+	return err == nil && mediaType == "multipart/form-data"
+}
+
+func newMultipartParser(request *internal.Request, dir string) (*multipartParser, error) {
+	_, params, _ := mime.ParseMediaType(headerValue(request.Headers, "Content-Type"))
+	boundary := params["boundary"]
+	if boundary == "" {
+		return nil, newSyntaxError(codeInvalidMultipart, "add a boundary to the Content-Type header, e.g. 'multipart/form-data; boundary=WebAppBoundary'",
+			"missing boundary in multipart Content-Type")
+	}
+	if err := multipart.NewWriter(nil).SetBoundary(boundary); err != nil {
+		return nil, newSyntaxError(codeInvalidMultipart, "use a boundary of 1 to 70 letters, digits and the characters '()+_,-./:=?",
+			"invalid multipart boundary")
+	}
+
+	request.Multipart = &internal.MultipartBody{Boundary: boundary}
+	return &multipartParser{
+		dir:       dir,
+		delimiter: "--" + boundary,
+		body:      request.Multipart,
+	}, nil
+}
+
+// add consumes a line of the multipart body and reports whether it was the closing delimiter.
+// The trimmed line is used to detect boundaries and headers, the raw line is kept as content.
+func (p *multipartParser) add(line string, raw string, lineCounter int) (bool, error) {
+	if line == p.delimiter || line == p.delimiter+"--" {
+		err := p.finish()
+		if line != p.delimiter {
+			return true, err
+		}
+		p.part = &internal.MultipartPart{Headers: map[string]string{}}
+		p.partLine = lineCounter
+		p.inHeaders = true
+		return false, err
+	}
+
+	if p.part == nil {
+		if line == "" {
+			return false, nil
+		}
+		return false, newSyntaxError(codeInvalidMultipart, "start the body with the boundary line '"+p.delimiter+"'",
+			"expected multipart boundary '%s'", p.delimiter)
+	}
+
+	if p.inHeaders {
+		if line == "" {
+			p.inHeaders = false
+			return false, nil
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return false, newSyntaxError(codeInvalidMultipart, "separate header name and value with ':', or add a blank line before the part content",
+				"invalid multipart header")
+		}
+		p.part.Headers[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		return false, nil
+	}
+
+	if isFileReference(line) {
+		if p.part.File != nil || len(p.content) > 0 {
+			return false, partConflictError()
+		}
+		file, err := parseFileReference(line, p.dir)
+		if err != nil {
+			return false, err
+		}
+		p.part.File = file
+		return false, nil
+	}
+	if p.part.File != nil && line != "" {
+		return false, partConflictError()
+	}
+	p.content = append(p.content, raw)
+	return false, nil
+}
+
+// finish completes the current part, if any, and adds it to the body.
+func (p *multipartParser) finish() error {
+	if p.part == nil {
+		return nil
+	}
+	defer func() {
+		p.part = nil
+		p.content = nil
+	}()
+	if headerValue(p.part.Headers, "Content-Disposition") == "" {
+		err := newSyntaxError(codeInvalidMultipart, "add a header like 'Content-Disposition: form-data; name=\"field\"'",
+			"missing Content-Disposition header in multipart part")
+		err.line = p.partLine
+		return err
+	}
+
+	p.part.Content = trimTrailingSeparator(strings.Join(p.content, ""))
+	p.body.Parts = append(p.body.Parts, *p.part)
+	return nil
+}
+
+func partConflictError() error {
+	return newSyntaxError(codeBodyConflict, "use either inline content or a single file reference per part",
+		"multipart part cannot combine inline content and a file reference")
+}
+
+// headerValue returns the value of the header with the given name, ignoring case.
+func headerValue(headers map[string]string, name string) string {
+	for k, v := range headers {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,177 @@
+package parser
+
+import (
+	"github.com/fdrolshagen/jetter/internal"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseHttp_ShouldParseMultipartBody(t *testing.T) {
+	content := strings.TrimSpace(`
+		### Upload
+		POST http://localhost:8081/upload
+		Content-Type: multipart/form-data; boundary=WebAppBoundary
+
+		--WebAppBoundary
+		Content-Disposition: form-data; name="name"
+		Content-Type: text/plain
+
+		{{NAME}}
+		--WebAppBoundary
+		Content-Disposition: form-data; name="data"; filename="data.json"
+		Content-Type: application/json
+
+		< ./fixtures/data.json
+		--WebAppBoundary
+		Content-Disposition: form-data; name="image"; filename="image.png"
+
+		<@ ./fixtures/image.png
+		--WebAppBoundary--
+
+		> {% client.global.set("uploaded", "true"); %}
+
+		### Next
+		GET http://localhost:8081/users
+		`)
+
+	c, err := ParseHttp(strings.NewReader(content))
+
+	assert.Nil(t, err)
+	assert.Len(t, c.Requests, 2)
+
+	mp := c.Requests[0].Multipart
+	assert.NotNil(t, mp)
+	assert.Equal(t, "WebAppBoundary", mp.Boundary)
+	assert.Len(t, mp.Parts, 3)
+	assert.Equal(t, internal.MultipartPart{
+		Headers: map[string]string{
+			"Content-Disposition": `form-data; name="name"`,
+			"Content-Type":        "text/plain",
+		},
+		Content: "\t\t{{NAME}}",
+	}, mp.Parts[0])
+	assert.Equal(t, &internal.BodyFile{Path: filepath.Join("fixtures", "data.json")}, mp.Parts[1].File)
+	assert.Equal(t, &internal.BodyFile{Path: filepath.Join("fixtures", "image.png"), Raw: true}, mp.Parts[2].File)
+	assert.Empty(t, c.Requests[0].Body)
+	assert.NotEmpty(t, c.Requests[0].ResponseHandler)
+	assert.Nil(t, c.Requests[1].Multipart)
+}
+
+func TestParseHttp_ShouldParseXmlPartAsContent(t *testing.T) {
+	content := "###\nPOST http://localhost:8081/upload\nContent-Type: multipart/form-data; boundary=b\n\n--b\nContent-Disposition: form-data; name=\"doc\"\n\n<root>foo</root>\n--b--\n"
+
+	c, err := ParseHttp(strings.NewReader(content))
+
+	assert.Nil(t, err)
+	assert.Len(t, c.Requests[0].Multipart.Parts, 1)
+	assert.Nil(t, c.Requests[0].Multipart.Parts[0].File)
+	assert.Equal(t, "<root>foo</root>", c.Requests[0].Multipart.Parts[0].Content)
+}
+
+func TestParseHttp_ShouldParseMultipartWithoutClosingBoundary(t *testing.T) {
+	content := strings.TrimSpace(`
+		###
+		POST http://localhost:8081/upload
+		Content-Type: multipart/form-data; boundary=abc
+
+		--abc
+		Content-Disposition: form-data; name="a"
+
+		first line
+		second line
+
+		`)
+
+	c, err := ParseHttp(strings.NewReader(content))
+
+	assert.Nil(t, err)
+	assert.Len(t, c.Requests[0].Multipart.Parts, 1)
+	assert.Equal(t, "\t\tfirst line\n\t\tsecond line", c.Requests[0].Multipart.Parts[0].Content)
+}
+
+func TestParseHttp_MultipartErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{
+			name: "missing boundary",
+			content: `###
+				POST http://localhost/upload
+				Content-Type: multipart/form-data
+
+				--abc`,
+			err: "missing boundary in multipart Content-Type at line 5",
+		},
+		{
+			name: "content before first boundary",
+			content: `###
+				POST http://localhost/upload
+				Content-Type: multipart/form-data; boundary=abc
+
+				hello`,
+			err: "expected multipart boundary '--abc' at line 5",
+		},
+		{
+			name: "missing content disposition",
+			content: `###
+				POST http://localhost/upload
+				Content-Type: multipart/form-data; boundary=abc
+
+				--abc
+				Content-Type: text/plain
+
+				hello
+				--abc--`,
+			err: "missing Content-Disposition header in multipart part at line 5",
+		},
+		{
+			name: "invalid part header",
+			content: `###
+				POST http://localhost/upload
+				Content-Type: multipart/form-data; boundary=abc
+
+				--abc
+				Content-Disposition form-data`,
+			err: "invalid multipart header at line 6",
+		},
+		{
+			name: "inline content and file",
+			content: `###
+				POST http://localhost/upload
+				Content-Type: multipart/form-data; boundary=abc
+
+				--abc
+				Content-Disposition: form-data; name="a"
+
+				hello
+				< ./a.txt`,
+			err: "cannot combine inline content and a file reference at line 9",
+		},
+		{
+			name: "content after closing boundary",
+			content: `###
+				POST http://localhost/upload
+				Content-Type: multipart/form-data; boundary=abc
+
+				--abc
+				Content-Disposition: form-data; name="a"
+
+				hello
+				--abc--
+				trailing`,
+			err: "unexpected content after closing multipart boundary at line 10",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseHttp(strings.NewReader(tt.content))
+			assert.NotNil(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
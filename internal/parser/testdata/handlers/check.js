@@ -0,0 +1,3 @@
+client.test("created", function() {
+  client.assert(response.status === 201);
+});
@@ -0,0 +1,54 @@
+package parser
+
+import (
+	"github.com/fdrolshagen/jetter/internal"
+	"strings"
+)
+
+// webSocketMethod is the pseudo method of IntelliJ WebSocket requests.
+const webSocketMethod = "WEBSOCKET"
+
+// waitForServer is the option of a message separator that waits for a message of the server.
+const waitForServer = "wait-for-server"
+
+func isMessageSeparator(line string) bool {
+	return strings.HasPrefix(line, "===")
+}
+
+// handleMessageSeparator checks a `===` line within the body of a WebSocket request.
+func handleMessageSeparator(line string) error {
+	option := strings.TrimSpace(strings.TrimPrefix(line, "==="))
+	if option != "" && option != waitForServer {
+		return newSyntaxError(codeInvalidSeparator, "use '===' to separate messages or '=== wait-for-server' to wait for the server",
+			"unknown message separator option '%s'", option).at(columnOf(line, option))
+	}
+	return nil
+}
+
+// newWebSocket splits the body of a WebSocket request into its steps. Messages are separated
+// by `===` lines, each `=== wait-for-server` line waits for one message of the server
+// before the next message is sent. Blank lines around messages are removed.
+func newWebSocket(body string) *internal.WebSocket {
+	ws := &internal.WebSocket{}
+	var message strings.Builder
+	flush := func() {
+		if text := strings.Trim(message.String(), "\r\n"); strings.TrimSpace(text) != "" {
+			ws.Steps = append(ws.Steps, internal.WebSocketStep{Message: strings.TrimRight(text, " \t\r\n")})
+		}
+		message.Reset()
+	}
+
+	for _, line := range strings.SplitAfter(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if !isMessageSeparator(trimmed) {
+			message.WriteString(line)
+			continue
+		}
+		flush()
+		if strings.TrimSpace(strings.TrimPrefix(trimmed, "===")) == waitForServer {
+			ws.Steps = append(ws.Steps, internal.WebSocketStep{WaitForServer: true})
+		}
+	}
+	flush()
+	return ws
+}
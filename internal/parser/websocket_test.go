@@ -0,0 +1,96 @@
+package parser
+
+import (
+	"github.com/fdrolshagen/jetter/internal"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestNewWebSocket(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		steps []internal.WebSocketStep
+	}{
+		{"empty", "", nil},
+		{"single message without separator", "hello\n", []internal.WebSocketStep{{Message: "hello"}}},
+		{
+			"messages and waits",
+			"===\n{\n  \"message\": \"hi\"\n}\n=== wait-for-server\n=== wait-for-server\n\nbye\n\n===   wait-for-server\n",
+			[]internal.WebSocketStep{
+				{Message: "{\n  \"message\": \"hi\"\n}"},
+				{WaitForServer: true},
+				{WaitForServer: true},
+				{Message: "bye"},
+				{WaitForServer: true},
+			},
+		},
+		{"empty messages are skipped", "===\n\n===\nping\r\n===\r\n", []internal.WebSocketStep{{Message: "ping"}}},
+		{"indentation is kept", "===\n  {\"a\": 1}\n", []internal.WebSocketStep{{Message: "  {\"a\": 1}"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.steps, newWebSocket(tt.body).Steps)
+		})
+	}
+}
+
+func TestParseHttp_ShouldParseWebSocketRequest(t *testing.T) {
+	content := strings.TrimSpace(`
+		### Chat
+		WEBSOCKET ws://localhost:8080/chat
+		Authorization: Bearer {{token}}
+
+		===
+		{"message": "Hello, {{NAME}}!"}
+		=== wait-for-server
+		===
+		{"message": "Bye"}
+		=== wait-for-server
+
+		### Next
+		GET http://localhost:8080/users
+		`)
+
+	c, err := ParseHttp(strings.NewReader(content))
+
+	assert.Nil(t, err)
+	assert.Len(t, c.Requests, 2)
+	r := c.Requests[0]
+	assert.Equal(t, "GET", r.Method)
+	assert.Equal(t, "ws://localhost:8080/chat", r.Url)
+	assert.Equal(t, "Bearer {{token}}", r.Headers["Authorization"])
+	assert.Empty(t, r.Body)
+	assert.Equal(t, &internal.WebSocket{Steps: []internal.WebSocketStep{
+		{Message: "\t\t{\"message\": \"Hello, {{NAME}}!\"}"},
+		{WaitForServer: true},
+		{Message: "\t\t{\"message\": \"Bye\"}"},
+		{WaitForServer: true},
+	}}, r.WebSocket)
+	assert.Nil(t, c.Requests[1].WebSocket)
+}
+
+func TestParseHttp_WebSocketErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		err     string
+		line    int
+		column  int
+	}{
+		{"unknown separator option", "###\nWEBSOCKET ws://localhost\n\n===\nhi\n=== wait-for-client", "unknown message separator option 'wait-for-client'", 6, 5},
+		{"file reference", "###\nWEBSOCKET ws://localhost\n\n< ./messages.json", "WebSocket requests cannot reference a file", 4, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseHttp(strings.NewReader(tt.content))
+			diagnostics := requireDiagnostics(t, err)
+			assert.Equal(t, tt.err, diagnostics[0].Message)
+			assert.Equal(t, tt.line, diagnostics[0].Line)
+			assert.Equal(t, tt.column, diagnostics[0].Column)
+		})
+	}
+}
@@ -2,26 +2,103 @@ package random
 
 import (
 	"crypto/rand"
+	"encoding/binary"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	mathrand "math/rand/v2"
 	"strconv"
 	"strings"
 )
 
-func Execute(funcName string, arg string) (string, error) {
+const (
+	letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	// wordChars are the characters of random.alphanumeric, which include the underscore like in IntelliJ.
+	wordChars = letters + "0123456789_"
+	// defaultMax is the exclusive upper bound of random.integer and random.float without arguments.
+	defaultMax = 1000
+)
+
+// Source generates the values of the random functions. Sources with the same seed and
+// stream generate the same values. A nil Source generates unpredictable values.
+// A Source is not safe for concurrent use.
+type Source struct {
+	rand *mathrand.Rand
+}
+
+// New returns a Source for the given seed and stream. Different streams of the same seed
+// generate independent values.
+func New(seed, stream uint64) *Source {
+	return &Source{rand: mathrand.New(mathrand.NewPCG(seed, stream))}
+}
+
+// NewSeed returns an unpredictable seed.
+func NewSeed() uint64 {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return binary.LittleEndian.Uint64(b[:])
+}
+
+func (s *Source) intN(n int) int {
+	if s == nil {
+		return mathrand.IntN(n)
+	}
+	return s.rand.IntN(n)
+}
+
+func (s *Source) int64N(n int64) int64 {
+	if s == nil {
+		return mathrand.Int64N(n)
+	}
+	return s.rand.Int64N(n)
+}
+
+func (s *Source) float64() float64 {
+	if s == nil {
+		return mathrand.Float64()
+	}
+	return s.rand.Float64()
+}
+
+// read fills b with random bytes.
+func (s *Source) read(b []byte) error {
+	if s == nil {
+		_, err := rand.Read(b)
+		return err
+	}
+	for i := 0; i < len(b); i += 8 {
+		var chunk [8]byte
+		binary.LittleEndian.PutUint64(chunk[:], s.rand.Uint64())
+		copy(b[i:], chunk[:])
+	}
+	return nil
+}
+
+// Execute evaluates the function `$random.<funcName>(<arg>)`. arg holds the comma separated
+// arguments, it is empty for functions given without parentheses.
+func (s *Source) Execute(funcName string, arg string) (string, error) {
 	switch funcName {
 	case "hexadecimal":
-		return hexadecimal(arg)
+		return s.hexadecimal(arg)
 	case "uuid":
-		return uuid()
+		return s.uuid()
+	case "integer":
+		return s.integer(arg)
+	case "float":
+		return s.float(arg)
+	case "alphabetic":
+		return s.randomString("alphabetic", arg, letters)
+	case "alphanumeric":
+		return s.randomString("alphanumeric", arg, wordChars)
+	case "email":
+		return s.email()
 	default:
 		return "", fmt.Errorf("unsupported random function: %s", funcName)
 	}
 }
 
-func hexadecimal(arg string) (string, error) {
-	length, err := strconv.Atoi(arg)
+func (s *Source) hexadecimal(arg string) (string, error) {
+	length, err := strconv.Atoi(strings.TrimSpace(arg))
 	if err != nil {
 		return "", errors.New("invalid argument for random.hexadecimal, must be integer")
 	}
@@ -30,7 +107,7 @@ func hexadecimal(arg string) (string, error) {
 	}
 
 	bytes := make([]byte, (length+1)/2)
-	if _, err := rand.Read(bytes); err != nil {
+	if err := s.read(bytes); err != nil {
 		return "", err
 	}
 	hexStr := hex.EncodeToString(bytes)
@@ -38,9 +115,9 @@ func hexadecimal(arg string) (string, error) {
 	return hexStr[:length], nil
 }
 
-func uuid() (string, error) {
+func (s *Source) uuid() (string, error) {
 	b := make([]byte, 16)
-	if _, err := rand.Read(b); err != nil {
+	if err := s.read(b); err != nil {
 		return "", err
 	}
 
@@ -57,3 +134,69 @@ func uuid() (string, error) {
 
 	return uuidStr, nil
 }
+
+// integer returns a random integer within [from, to), or [0, 1000) without arguments.
+func (s *Source) integer(arg string) (string, error) {
+	from, to, err := bounds("integer", arg)
+	if err != nil {
+		return "", err
+	}
+	lo, hi := int64(from), int64(to)
+	if float64(lo) != from || float64(hi) != to {
+		return "", errors.New("invalid arguments for random.integer, must be integers")
+	}
+	return strconv.FormatInt(lo+s.int64N(hi-lo), 10), nil
+}
+
+// float returns a random float within [from, to), or [0, 1000) without arguments.
+func (s *Source) float(arg string) (string, error) {
+	from, to, err := bounds("float", arg)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatFloat(from+s.float64()*(to-from), 'f', -1, 64), nil
+}
+
+// bounds parses the arguments `from, to` of a random number function.
+func bounds(funcName, arg string) (float64, float64, error) {
+	if strings.TrimSpace(arg) == "" {
+		return 0, defaultMax, nil
+	}
+	fromArg, toArg, ok := strings.Cut(arg, ",")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid arguments for random.%s, must be 'from, to'", funcName)
+	}
+	from, err1 := strconv.ParseFloat(strings.TrimSpace(fromArg), 64)
+	to, err2 := strconv.ParseFloat(strings.TrimSpace(toArg), 64)
+	if err1 != nil || err2 != nil {
+		return 0, 0, fmt.Errorf("invalid arguments for random.%s, must be numbers", funcName)
+	}
+	if from >= to {
+		return 0, 0, fmt.Errorf("invalid arguments for random.%s, from must be less than to", funcName)
+	}
+	return from, to, nil
+}
+
+func (s *Source) randomString(funcName, arg, chars string) (string, error) {
+	length, err := strconv.Atoi(strings.TrimSpace(arg))
+	if err != nil {
+		return "", fmt.Errorf("invalid argument for random.%s, must be integer", funcName)
+	}
+	if length <= 0 {
+		return "", errors.New("length must be > 0")
+	}
+	return s.pick(length, chars), nil
+}
+
+// email returns a random email address of the example.com domain.
+func (s *Source) email() (string, error) {
+	return strings.ToLower(s.pick(6, letters)+"."+s.pick(8, letters)) + "@example.com", nil
+}
+
+func (s *Source) pick(length int, chars string) string {
+	b := make([]byte, length)
+	for i := range b {
+		b[i] = chars[s.intN(len(chars))]
+	}
+	return string(b)
+}
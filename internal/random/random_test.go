@@ -0,0 +1,118 @@
+package random
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+var functions = []struct {
+	name string
+	arg  string
+}{
+	{"hexadecimal", "8"},
+	{"uuid", ""},
+	{"integer", "1, 1000000"},
+	{"float", ""},
+	{"alphabetic", "10"},
+	{"alphanumeric", "10"},
+	{"email", ""},
+}
+
+func values(t *testing.T, s *Source) []string {
+	t.Helper()
+	var result []string
+	for _, f := range functions {
+		value, err := s.Execute(f.name, f.arg)
+		require.NoError(t, err, f.name)
+		result = append(result, value)
+	}
+	return result
+}
+
+func TestSource_SameSeedAndStreamGenerateSameValues(t *testing.T) {
+	first := values(t, New(42, 7))
+
+	assert.Equal(t, first, values(t, New(42, 7)))
+	assert.NotEqual(t, first, values(t, New(43, 7)))
+	assert.NotEqual(t, first, values(t, New(42, 8)))
+}
+
+func TestSource_NilSourceGeneratesUnpredictableValues(t *testing.T) {
+	var s *Source
+
+	assert.NotEqual(t, values(t, s), values(t, s))
+}
+
+func TestSource_Execute(t *testing.T) {
+	tests := []struct {
+		funcName string
+		arg      string
+		pattern  string
+	}{
+		{"hexadecimal", "5", `^[0-9A-F]{5}$`},
+		{"uuid", "", `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`},
+		{"integer", "-3, 3", `^-?[0-9]$`},
+		{"float", "0.5, 1.5", `^[01](\.[0-9]+)?$`},
+		{"alphabetic", "6", `^[a-zA-Z]{6}$`},
+		{"alphanumeric", "6", `^\w{6}$`},
+		{"email", "", `^[a-z]{6}\.[a-z]{8}@example\.com$`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.funcName, func(t *testing.T) {
+			for _, s := range []*Source{nil, New(1, 2)} {
+				value, err := s.Execute(tt.funcName, tt.arg)
+				assert.NoError(t, err)
+				assert.Regexp(t, regexp.MustCompile(tt.pattern), value)
+			}
+		})
+	}
+}
+
+func TestSource_NumbersStayWithinBounds(t *testing.T) {
+	s := New(1, 1)
+	for i := 0; i < 1000; i++ {
+		value, err := s.Execute("integer", "-3, 3")
+		require.NoError(t, err)
+		n, err := strconv.Atoi(value)
+		require.NoError(t, err)
+		assert.True(t, n >= -3 && n < 3, value)
+
+		value, err = s.Execute("float", "")
+		require.NoError(t, err)
+		f, err := strconv.ParseFloat(value, 64)
+		require.NoError(t, err)
+		assert.True(t, f >= 0 && f < defaultMax, value)
+	}
+}
+
+func TestSource_ExecuteErrors(t *testing.T) {
+	tests := []struct {
+		funcName string
+		arg      string
+		err      string
+	}{
+		{"unknown", "", "unsupported random function: unknown"},
+		{"hexadecimal", "x", "invalid argument for random.hexadecimal, must be integer"},
+		{"hexadecimal", "0", "length must be > 0"},
+		{"integer", "5", "invalid arguments for random.integer, must be 'from, to'"},
+		{"integer", "a, 5", "invalid arguments for random.integer, must be numbers"},
+		{"integer", "5, 5", "invalid arguments for random.integer, from must be less than to"},
+		{"integer", "1.5, 5", "invalid arguments for random.integer, must be integers"},
+		{"float", "2, 1", "invalid arguments for random.float, from must be less than to"},
+		{"alphabetic", "", "invalid argument for random.alphabetic, must be integer"},
+		{"alphanumeric", "-1", "length must be > 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.funcName+"("+tt.arg+")", func(t *testing.T) {
+			for _, s := range []*Source{nil, New(1, 2)} {
+				_, err := s.Execute(tt.funcName, tt.arg)
+				assert.EqualError(t, err, tt.err)
+			}
+		})
+	}
+}
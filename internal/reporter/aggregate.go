@@ -16,19 +16,61 @@ type Metrics struct {
 	Average     time.Duration
 	Durations   []time.Duration
 	StatusCodes map[int]int
+	// GRPCStatusCodes counts the gRPC status codes by name, e.g. "OK" or "NotFound".
+	GRPCStatusCodes map[string]int
+	TestsPassed     int
+	TestsFailed     int
+	WebSocket       *WebSocketMetrics
+	SSE             *SSEMetrics
+}
+
+// WebSocketMetrics summarizes the WebSocket connections of a request. Handshake and
+// MessagesPerSecond are averaged over the connections, RoundTrip over all round trips.
+type WebSocketMetrics struct {
+	Connections       int
+	Handshake         time.Duration
+	RoundTrip         time.Duration
+	MessagesPerSecond float64
+}
+
+// SSEMetrics summarizes the server-sent event streams of a request. FirstEvent and Events
+// are averaged over the streams, Gap over all gaps between consecutive events.
+type SSEMetrics struct {
+	Streams    int
+	FirstEvent time.Duration
+	Gap        time.Duration
+	Events     float64
+}
+
+// webSocketSamples collects the measurements of the WebSocket connections of a request.
+type webSocketSamples struct {
+	handshakes []time.Duration
+	roundTrips []time.Duration
+	rates      []float64
+}
+
+// sseSamples collects the measurements of the event streams of a request.
+type sseSamples struct {
+	streams     int
+	firstEvents []time.Duration
+	gaps        []time.Duration
+	events      int
 }
 
 func Aggregate(result internal.Result) []Metrics {
 	m := make(map[int]*Metrics)
+	samples := make(map[int]*webSocketSamples)
+	streams := make(map[int]*sseSamples)
 
 	for _, exec := range result.Executions {
 		for _, resp := range exec.Responses {
 			metric, ok := m[resp.Index]
 			if !ok {
 				metric = &Metrics{
-					Index:       resp.Index,
-					Name:        resp.Name,
-					StatusCodes: make(map[int]int),
+					Index:           resp.Index,
+					Name:            resp.Name,
+					StatusCodes:     make(map[int]int),
+					GRPCStatusCodes: make(map[string]int),
 				}
 				m[resp.Index] = metric
 			}
@@ -41,10 +83,50 @@ func Aggregate(result internal.Result) []Metrics {
 				metric.StatusCodes[resp.Status]++
 			}
 
+			// Count gRPC status codes
+			if resp.GRPCStatus != "" {
+				metric.GRPCStatusCodes[resp.GRPCStatus]++
+			}
+
+			// Count response handler tests
+			for _, t := range resp.Tests {
+				if t.Passed {
+					metric.TestsPassed++
+				} else {
+					metric.TestsFailed++
+				}
+			}
+
 			// Count failures
-			if resp.Error != nil || resp.Status >= 400 {
+			if resp.Failed() {
 				metric.Failed++
 			}
+
+			if resp.WebSocket != nil {
+				ws, ok := samples[resp.Index]
+				if !ok {
+					ws = &webSocketSamples{}
+					samples[resp.Index] = ws
+				}
+				ws.handshakes = append(ws.handshakes, resp.WebSocket.Handshake)
+				ws.roundTrips = append(ws.roundTrips, resp.WebSocket.RoundTrips...)
+				ws.rates = append(ws.rates, resp.WebSocket.MessagesPerSecond())
+			}
+
+			if resp.SSE != nil {
+				sse, ok := streams[resp.Index]
+				if !ok {
+					sse = &sseSamples{}
+					streams[resp.Index] = sse
+				}
+				sse.streams++
+				// Streams without events have no time to first event.
+				if resp.SSE.Events > 0 {
+					sse.firstEvents = append(sse.firstEvents, resp.SSE.FirstEvent)
+				}
+				sse.gaps = append(sse.gaps, resp.SSE.Gaps...)
+				sse.events += resp.SSE.Events
+			}
 		}
 	}
 
@@ -54,6 +136,24 @@ func Aggregate(result internal.Result) []Metrics {
 		mm.Fastest = mm.Durations[0].Round(time.Millisecond)
 		mm.Slowest = mm.Durations[len(mm.Durations)-1].Round(time.Millisecond)
 		mm.Average = mean(mm.Durations).Round(time.Millisecond)
+		if ws, ok := samples[mm.Index]; ok {
+			mm.WebSocket = &WebSocketMetrics{
+				Connections: len(ws.handshakes),
+				Handshake:   mean(ws.handshakes).Round(time.Millisecond),
+				RoundTrip:   mean(ws.roundTrips).Round(time.Millisecond),
+			}
+			for _, rate := range ws.rates {
+				mm.WebSocket.MessagesPerSecond += rate / float64(len(ws.rates))
+			}
+		}
+		if sse, ok := streams[mm.Index]; ok {
+			mm.SSE = &SSEMetrics{
+				Streams:    sse.streams,
+				FirstEvent: mean(sse.firstEvents).Round(time.Millisecond),
+				Gap:        mean(sse.gaps).Round(time.Millisecond),
+				Events:     float64(sse.events) / float64(sse.streams),
+			}
+		}
 		items = append(items, *mm)
 	}
 
@@ -99,4 +99,133 @@ func TestAggregate(t *testing.T) {
 		assert.Equal(t, map[int]int{200: 1}, metrics[0].StatusCodes)
 		assert.Equal(t, map[int]int{503: 1}, metrics[1].StatusCodes)
 	})
+
+	t.Run("counts response handler tests", func(t *testing.T) {
+		result := internal.Result{
+			Executions: []internal.Execution{
+				{
+					Responses: []internal.Response{
+						{Index: 0, Name: "GET /ping", Status: 200, Duration: 10 * time.Millisecond,
+							Tests: []internal.TestResult{{Name: "ok", Passed: true}}},
+						{Index: 0, Name: "GET /ping", Status: 200, Duration: 10 * time.Millisecond,
+							Tests: []internal.TestResult{{Name: "ok", Passed: false, Message: "boom"}}},
+					},
+				},
+			},
+		}
+
+		metrics := Aggregate(result)
+		assert.Len(t, metrics, 1)
+		assert.Equal(t, 1, metrics[0].TestsPassed)
+		assert.Equal(t, 1, metrics[0].TestsFailed)
+		assert.Equal(t, 1, metrics[0].Failed)
+	})
+
+	t.Run("counts GraphQL errors as failures", func(t *testing.T) {
+		result := internal.Result{
+			Executions: []internal.Execution{
+				{
+					Responses: []internal.Response{
+						{Index: 0, Name: "GetUser", Status: 200, Duration: 10 * time.Millisecond},
+						{Index: 0, Name: "GetUser", Status: 200, Duration: 10 * time.Millisecond, GraphQLErrors: 1},
+					},
+				},
+			},
+		}
+
+		metrics := Aggregate(result)
+		assert.Len(t, metrics, 1)
+		assert.Equal(t, 2, metrics[0].Total)
+		assert.Equal(t, 1, metrics[0].Failed)
+		assert.Equal(t, map[int]int{200: 2}, metrics[0].StatusCodes)
+	})
+
+	t.Run("aggregates WebSocket connections", func(t *testing.T) {
+		result := internal.Result{
+			Executions: []internal.Execution{
+				{
+					Responses: []internal.Response{
+						{Index: 0, Name: "Chat", Status: 101, Duration: 3 * time.Second, WebSocket: &internal.WebSocketStats{
+							Handshake:  10 * time.Millisecond,
+							RoundTrips: []time.Duration{20 * time.Millisecond, 40 * time.Millisecond},
+							Sent:       2,
+							Received:   2,
+							Open:       2 * time.Second,
+						}},
+						{Index: 0, Name: "Chat", Status: 101, Duration: time.Second, WebSocket: &internal.WebSocketStats{
+							Handshake:  30 * time.Millisecond,
+							RoundTrips: []time.Duration{60 * time.Millisecond},
+							Sent:       1,
+							Received:   1,
+							Open:       time.Second,
+						}},
+						{Index: 1, Name: "GET /ping", Status: 200, Duration: 10 * time.Millisecond},
+					},
+				},
+			},
+		}
+
+		metrics := Aggregate(result)
+		assert.Len(t, metrics, 2)
+		assert.Equal(t, &WebSocketMetrics{
+			Connections:       2,
+			Handshake:         20 * time.Millisecond,
+			RoundTrip:         40 * time.Millisecond,
+			MessagesPerSecond: 2,
+		}, metrics[0].WebSocket)
+		assert.Equal(t, map[int]int{101: 2}, metrics[0].StatusCodes)
+		assert.Nil(t, metrics[1].WebSocket)
+	})
+
+	t.Run("counts gRPC status codes", func(t *testing.T) {
+		result := internal.Result{
+			Executions: []internal.Execution{
+				{
+					Responses: []internal.Response{
+						{Index: 0, Name: "SayHello", GRPCStatus: "OK", Duration: 10 * time.Millisecond},
+						{Index: 0, Name: "SayHello", GRPCStatus: "OK", Duration: 20 * time.Millisecond},
+						{Index: 0, Name: "SayHello", GRPCStatus: "NotFound", Duration: 30 * time.Millisecond},
+					},
+				},
+			},
+		}
+
+		metrics := Aggregate(result)
+		assert.Len(t, metrics, 1)
+		assert.Equal(t, 1, metrics[0].Failed)
+		assert.Equal(t, map[string]int{"OK": 2, "NotFound": 1}, metrics[0].GRPCStatusCodes)
+		assert.Empty(t, metrics[0].StatusCodes)
+	})
+
+	t.Run("aggregates SSE streams", func(t *testing.T) {
+		result := internal.Result{
+			Executions: []internal.Execution{
+				{
+					Responses: []internal.Response{
+						{Index: 0, Name: "Events", Status: 200, Duration: time.Second, SSE: &internal.SSEStats{
+							Events:     3,
+							FirstEvent: 10 * time.Millisecond,
+							Gaps:       []time.Duration{100 * time.Millisecond, 200 * time.Millisecond},
+						}},
+						{Index: 0, Name: "Events", Status: 200, Duration: time.Second, SSE: &internal.SSEStats{
+							Events:     1,
+							FirstEvent: 30 * time.Millisecond,
+						}},
+						{Index: 0, Name: "Events", Status: 200, Duration: time.Second, SSE: &internal.SSEStats{}},
+						{Index: 1, Name: "GET /ping", Status: 200, Duration: 10 * time.Millisecond},
+					},
+				},
+			},
+		}
+
+		metrics := Aggregate(result)
+		assert.Len(t, metrics, 2)
+		assert.Equal(t, &SSEMetrics{
+			Streams:    3,
+			FirstEvent: 20 * time.Millisecond,
+			Gap:        150 * time.Millisecond,
+			Events:     4.0 / 3,
+		}, metrics[0].SSE)
+		assert.Nil(t, metrics[1].SSE)
+	})
 }
@@ -1,6 +1,7 @@
 package reporter
 
 import (
+	"fmt"
 	"github.com/fdrolshagen/jetter/internal"
 )
 
@@ -10,4 +11,5 @@ func Report(r internal.Result) {
 	if err != nil {
 		return
 	}
+	fmt.Printf("\nSeed: %d (reproduce with --seed %d)\n", r.Seed, r.Seed)
 }
@@ -10,26 +10,71 @@ import (
 	"time"
 )
 
+// TableReport prints the metrics as table. The WebSocket and SSE columns are only shown
+// if any of the requests is a WebSocket or SSE request.
 func TableReport(metrics []Metrics) error {
-	table := configureTableWriter()
+	webSocket, sse := hasWebSocketMetrics(metrics), hasSSEMetrics(metrics)
+	table := configureTableWriter(webSocket, sse)
 
 	for _, m := range metrics {
 
-		table.Append([]string{
+		row := []string{
 			m.Name,
 			fmt.Sprintf("%d", m.Total),
 			colorDuration(m.Fastest, m.Fastest, m.Slowest),
 			colorDuration(m.Slowest, m.Fastest, m.Slowest),
 			colorMean(m.Average, m.Fastest, m.Slowest),
 			formatTotalFailed(m.Failed),
-			formatStatusCodes(m.StatusCodes),
-		})
+			formatStatusCodes(m.StatusCodes, m.GRPCStatusCodes),
+			formatTests(m.TestsPassed, m.TestsFailed),
+		}
+		if webSocket {
+			row = append(row, formatWebSocket(m.WebSocket)...)
+		}
+		if sse {
+			row = append(row, formatSSE(m.SSE)...)
+		}
+		table.Append(row)
 	}
 
 	table.Render()
 	return nil
 }
 
+func hasWebSocketMetrics(metrics []Metrics) bool {
+	for _, m := range metrics {
+		if m.WebSocket != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func hasSSEMetrics(metrics []Metrics) bool {
+	for _, m := range metrics {
+		if m.SSE != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// formatWebSocket returns the handshake, round trip and messages per second columns.
+func formatWebSocket(ws *WebSocketMetrics) []string {
+	if ws == nil {
+		return []string{"-", "-", "-"}
+	}
+	return []string{ws.Handshake.String(), ws.RoundTrip.String(), fmt.Sprintf("%.1f", ws.MessagesPerSecond)}
+}
+
+// formatSSE returns the time to first event, event gap and events per stream columns.
+func formatSSE(sse *SSEMetrics) []string {
+	if sse == nil {
+		return []string{"-", "-", "-"}
+	}
+	return []string{sse.FirstEvent.String(), sse.Gap.String(), fmt.Sprintf("%.1f", sse.Events)}
+}
+
 func colorDuration(d, fastest, longest time.Duration) string {
 	durStr := d.String()
 	switch {
@@ -65,7 +110,22 @@ func formatTotalFailed(countFailed int) string {
 	return color.GreenString("0")
 }
 
-func formatStatusCodes(codes map[int]int) string {
+func formatTests(passed, failed int) string {
+	total := passed + failed
+	if total == 0 {
+		return "-"
+	}
+	if failed > 0 {
+		return color.RedString("%d/%d", passed, total)
+	}
+	return color.GreenString("%d/%d", passed, total)
+}
+
+// formatStatusCodes returns the HTTP status codes, or the gRPC status codes of gRPC requests.
+func formatStatusCodes(codes map[int]int, grpcCodes map[string]int) string {
+	if len(grpcCodes) > 0 {
+		return formatGRPCStatusCodes(grpcCodes)
+	}
 	if len(codes) == 0 {
 		return "-"
 	}
@@ -98,14 +158,28 @@ func formatStatusCodes(codes map[int]int) string {
 	return strings.Join(parts, "   ")
 }
 
-func configureTableWriter() *tablewriter.Table {
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Name", "Total", "Fastest", "Longest", "Mean", "Failed", "Status Codes"})
-	table.SetBorders(tablewriter.Border{Left: false, Top: true, Right: false, Bottom: true})
-	table.SetAutoWrapText(false)
-	table.SetHeaderAlignment(tablewriter.ALIGN_CENTER)
-	table.SetAlignment(tablewriter.ALIGN_CENTER)
-	table.SetColumnAlignment([]int{
+func formatGRPCStatusCodes(codes map[string]int) string {
+	keys := make([]string, 0, len(codes))
+	for k := range codes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, code := range keys {
+		if code == "OK" {
+			parts = append(parts, color.GreenString("%d × %s", codes[code], code))
+		} else {
+			parts = append(parts, color.RedString("%d × %s", codes[code], code))
+		}
+	}
+
+	return strings.Join(parts, "   ")
+}
+
+func configureTableWriter(webSocket, sse bool) *tablewriter.Table {
+	header := []string{"Name", "Total", "Fastest", "Longest", "Mean", "Failed", "Status Codes", "Tests"}
+	alignment := []int{
 		tablewriter.ALIGN_LEFT,
 		tablewriter.ALIGN_RIGHT,
 		tablewriter.ALIGN_RIGHT,
@@ -113,20 +187,33 @@ func configureTableWriter() *tablewriter.Table {
 		tablewriter.ALIGN_RIGHT,
 		tablewriter.ALIGN_CENTER,
 		tablewriter.ALIGN_CENTER,
-	})
+		tablewriter.ALIGN_CENTER,
+	}
+	if webSocket {
+		header = append(header, "Handshake", "Round Trip", "Msgs/s")
+		alignment = append(alignment, tablewriter.ALIGN_RIGHT, tablewriter.ALIGN_RIGHT, tablewriter.ALIGN_RIGHT)
+	}
+	if sse {
+		header = append(header, "First Event", "Event Gap", "Events")
+		alignment = append(alignment, tablewriter.ALIGN_RIGHT, tablewriter.ALIGN_RIGHT, tablewriter.ALIGN_RIGHT)
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader(header)
+	table.SetBorders(tablewriter.Border{Left: false, Top: true, Right: false, Bottom: true})
+	table.SetAutoWrapText(false)
+	table.SetHeaderAlignment(tablewriter.ALIGN_CENTER)
+	table.SetAlignment(tablewriter.ALIGN_CENTER)
+	table.SetColumnAlignment(alignment)
 	table.SetHeaderLine(true)
 	table.SetRowLine(true)
 	table.SetCenterSeparator("│")
 	table.SetColumnSeparator("│")
 	table.SetRowSeparator("─")
-	table.SetHeaderColor(
-		tablewriter.Colors{tablewriter.Bold, tablewriter.FgHiWhiteColor},
-		tablewriter.Colors{tablewriter.Bold, tablewriter.FgHiWhiteColor},
-		tablewriter.Colors{tablewriter.Bold, tablewriter.FgHiWhiteColor},
-		tablewriter.Colors{tablewriter.Bold, tablewriter.FgHiWhiteColor},
-		tablewriter.Colors{tablewriter.Bold, tablewriter.FgHiWhiteColor},
-		tablewriter.Colors{tablewriter.Bold, tablewriter.FgHiWhiteColor},
-		tablewriter.Colors{tablewriter.Bold, tablewriter.FgHiWhiteColor},
-	)
+	colors := make([]tablewriter.Colors, len(header))
+	for i := range colors {
+		colors[i] = tablewriter.Colors{tablewriter.Bold, tablewriter.FgHiWhiteColor}
+	}
+	table.SetHeaderColor(colors...)
 	return table
 }
@@ -0,0 +1,108 @@
+package internal
+
+import (
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// referenceRegex matches a reference to another variable, e.g. `{{URL}}`. Names are taken
+// as written, so `{{ URL }}` is no reference. Dynamic variables starting with `$` are no
+// references either.
+var referenceRegex = regexp.MustCompile(`\{\{([^{}$\s](?:[^{}]*[^{}\s])?)}}`)
+
+// CycleError reports variables that reference each other. Chain starts and ends with
+// the same variable, e.g. `a -> b -> a`.
+type CycleError struct {
+	Chain []string
+}
+
+func (e *CycleError) Error() string {
+	return "variable cycle: " + strings.Join(e.Chain, " -> ")
+}
+
+// ResolveVariables substitutes the references between the given variables, so that no
+// value refers to another one of them. References to unknown variables are kept, as they
+// may be globals set at runtime. Variables referencing each other return a *CycleError.
+func ResolveVariables(vars map[string]string) (map[string]string, error) {
+	if !slices.ContainsFunc(slices.Collect(maps.Values(vars)), hasReference) {
+		return vars, nil
+	}
+
+	r := resolver{vars: vars, resolved: make(map[string]string, len(vars))}
+	// Names are resolved in order, so that the same cycle is reported on every run.
+	for _, name := range slices.Sorted(maps.Keys(vars)) {
+		if _, err := r.resolve(name); err != nil {
+			return nil, err
+		}
+	}
+	return r.resolved, nil
+}
+
+// resolver resolves variables depth first. path holds the variables currently being
+// resolved, a reference to one of them closes a cycle.
+type resolver struct {
+	vars     map[string]string
+	resolved map[string]string
+	path     []string
+}
+
+func (r *resolver) resolve(name string) (string, error) {
+	if value, ok := r.resolved[name]; ok {
+		return value, nil
+	}
+	if i := slices.Index(r.path, name); i >= 0 {
+		return "", &CycleError{Chain: append(slices.Clone(r.path[i:]), name)}
+	}
+
+	r.path = append(r.path, name)
+	defer func() { r.path = r.path[:len(r.path)-1] }()
+
+	var err error
+	value := referenceRegex.ReplaceAllStringFunc(r.vars[name], func(ref string) string {
+		refName := ref[2 : len(ref)-2]
+		if _, ok := r.vars[refName]; !ok || err != nil {
+			return ref
+		}
+		var value string
+		if value, err = r.resolve(refName); err != nil {
+			return ref
+		}
+		return value
+	})
+	if err != nil {
+		return "", err
+	}
+	r.resolved[name] = value
+	return value, nil
+}
+
+// SubstituteReferences replaces the references to the given resolved variables within s.
+// References to other variables are kept.
+func SubstituteReferences(s string, vars map[string]string) string {
+	if !hasReference(s) {
+		return s
+	}
+	return referenceRegex.ReplaceAllStringFunc(s, func(ref string) string {
+		if value, ok := vars[ref[2:len(ref)-2]]; ok {
+			return value
+		}
+		return ref
+	})
+}
+
+// ReferenceName returns the name of the variable the placeholder refers to, e.g. `URL`
+// for `{{URL}}`. It reports false for dynamic variables and placeholders that are no
+// reference, such as `{{ URL }}`.
+func ReferenceName(placeholder string) (string, bool) {
+	m := referenceRegex.FindStringSubmatch(placeholder)
+	if m == nil || m[0] != placeholder {
+		return "", false
+	}
+	return m[1], true
+}
+
+func hasReference(s string) bool {
+	return strings.Contains(s, "{{")
+}
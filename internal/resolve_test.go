@@ -0,0 +1,75 @@
+package internal
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestResolveVariables(t *testing.T) {
+	tests := []struct {
+		name string
+		vars map[string]string
+		want map[string]string
+		err  string
+	}{
+		{
+			name: "without references",
+			vars: map[string]string{"A": "1", "B": "{{$random.uuid()}}"},
+			want: map[string]string{"A": "1", "B": "{{$random.uuid()}}"},
+		},
+		{
+			name: "chained references",
+			vars: map[string]string{"api": "{{URL}}/v2", "URL": "http://{{HOST}}:{{PORT}}", "HOST": "localhost", "PORT": "8080"},
+			want: map[string]string{"api": "http://localhost:8080/v2", "URL": "http://localhost:8080", "HOST": "localhost", "PORT": "8080"},
+		},
+		{
+			name: "unknown references are kept",
+			vars: map[string]string{"auth": "Bearer {{token}}", "PREFIX": "{{ auth }}"},
+			want: map[string]string{"auth": "Bearer {{token}}", "PREFIX": "{{ auth }}"},
+		},
+		{
+			name: "self reference",
+			vars: map[string]string{"a": "{{a}}"},
+			err:  "variable cycle: a -> a",
+		},
+		{
+			name: "cycle behind a chain",
+			vars: map[string]string{"a": "{{b}}", "b": "{{c}}", "c": "{{b}}"},
+			err:  "variable cycle: b -> c -> b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved, err := ResolveVariables(tt.vars)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, resolved)
+		})
+	}
+}
+
+func TestReferenceName(t *testing.T) {
+	tests := []struct {
+		placeholder string
+		name        string
+		ok          bool
+	}{
+		{"{{URL}}", "URL", true},
+		{"{{a b}}", "a b", true},
+		{"{{ URL }}", "", false},
+		{"{{URL }}", "", false},
+		{"{{$uuid}}", "", false},
+		{"{{}}", "", false},
+		{"x{{URL}}", "", false},
+	}
+
+	for _, tt := range tests {
+		name, ok := ReferenceName(tt.placeholder)
+		assert.Equal(t, tt.name, name, tt.placeholder)
+		assert.Equal(t, tt.ok, ok, tt.placeholder)
+	}
+}
@@ -4,10 +4,12 @@ import "time"
 
 // Result represents the overall outcome of a scenario run within jetter.
 // It aggregates all Executions performed as part of the scenario and
-// indicates whether any of them encountered an error.
+// indicates whether any of them encountered an error. Seed is the seed of the random
+// values, which reproduces them in another run.
 type Result struct {
 	Executions []Execution
 	AnyError   bool
+	Seed       uint64
 }
 
 // Execution represents the result of a single scenario execution,
@@ -20,11 +22,78 @@ type Execution struct {
 
 // Response represents the outcome of a single request within a scenario execution.
 // It contains metadata such as the request name, response status,
-// execution duration, and any associated error.
+// execution duration, the results of the response handler tests, and any associated error.
+// GraphQLErrors is the number of entries in the `errors` array of a GraphQL response.
+// WebSocket holds the measurements of a WebSocket connection, whose Duration spans from
+// the start of the handshake until the connection is closed. gRPC requests report their
+// status code name, like `OK` or `NotFound`, as GRPCStatus instead of an HTTP Status.
+// SSE holds the measurements of a server-sent events stream, whose Duration spans until
+// the stream is closed.
 type Response struct {
-	Index    int
-	Name     string
-	Status   int
-	Duration time.Duration
-	Error    error
+	Index         int
+	Name          string
+	Status        int
+	GRPCStatus    string
+	Duration      time.Duration
+	Tests         []TestResult
+	GraphQLErrors int
+	WebSocket     *WebSocketStats
+	SSE           *SSEStats
+	Error         error
+}
+
+// WebSocketStats are the message-level measurements of a single WebSocket connection.
+// A round trip is the time from sending a message until the next message of the server
+// is received, it is measured for every wait-for-server step following a sent message.
+type WebSocketStats struct {
+	Handshake  time.Duration
+	RoundTrips []time.Duration
+	Sent       int
+	Received   int
+	// Open is the time the connection was open after the handshake.
+	Open time.Duration
+}
+
+// MessagesPerSecond returns the number of messages sent and received per second
+// while the connection was open.
+func (s WebSocketStats) MessagesPerSecond() float64 {
+	if s.Open <= 0 {
+		return 0
+	}
+	return float64(s.Sent+s.Received) / s.Open.Seconds()
+}
+
+// SSEStats are the event-level measurements of a single server-sent events stream.
+// FirstEvent is the time from sending the request until the first event is received,
+// Gaps are the times between consecutive events.
+type SSEStats struct {
+	Events     int
+	FirstEvent time.Duration
+	Gaps       []time.Duration
+}
+
+// TestResult represents the outcome of a single client.test call
+// within a response handler script.
+type TestResult struct {
+	Name    string
+	Passed  bool
+	Message string
+}
+
+// Failed reports whether the request encountered an error, responded with a 4xx or
+// 5xx status code, a gRPC status other than OK or GraphQL errors, or failed a response
+// handler test.
+func (r Response) Failed() bool {
+	return r.Error != nil || r.Status >= 400 || (r.GRPCStatus != "" && r.GRPCStatus != "OK") ||
+		r.GraphQLErrors > 0 || r.AnyTestFailed()
+}
+
+// AnyTestFailed reports whether at least one response handler test failed.
+func (r Response) AnyTestFailed() bool {
+	for _, t := range r.Tests {
+		if !t.Passed {
+			return true
+		}
+	}
+	return false
 }
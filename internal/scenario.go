@@ -1,12 +1,59 @@
 package internal
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 // Scenario represents an executable load or functional test definition within jetter.
 // It specifies which request collection to run, how many executions to perform concurrently,
-// and for how long the scenario should be executed.
+// for how long the scenario should be executed, how long to pause after each request,
+// which responses are written to files, the base URL of relative request URLs and the
+// seed of the random values of dynamic variables.
 type Scenario struct {
-	Collection  *Collection
-	Concurrency int
+	Collection   *Collection
+	Concurrency  int
+	Duration     time.Duration
+	ThinkTime    time.Duration
+	OutputPolicy OutputPolicy
+	BaseURL      string
+	Seed         uint64
+}
+
+// ScenarioConfig holds the load profile given by `#@jetter` directives at the top
+// of a .http file. Zero values mean that the setting is not given in the file.
+type ScenarioConfig struct {
 	Duration    time.Duration
+	Concurrency int
+	ThinkTime   time.Duration
+	// Strict reports requests with a non-standard method as warnings.
+	Strict bool
+	// Protos are the .proto files and descriptor sets used to resolve the messages of gRPC requests.
+	Protos []string
+	// BaseURL is the base URL of relative request URLs, it may contain variables.
+	BaseURL string
+	// Seed is the seed of the random values, nil if not given.
+	Seed *uint64
+}
+
+// OutputPolicy decides which responses of a request are written to its ResponseOutput.
+type OutputPolicy string
+
+const (
+	// OutputFirst writes only the first response of each request.
+	OutputFirst OutputPolicy = "first"
+	// OutputFailures writes only responses of failed requests.
+	OutputFailures OutputPolicy = "failures"
+	// OutputAll writes every response.
+	OutputAll OutputPolicy = "all"
+)
+
+// ParseOutputPolicy returns the OutputPolicy with the given name.
+func ParseOutputPolicy(name string) (OutputPolicy, error) {
+	switch p := OutputPolicy(name); p {
+	case OutputFirst, OutputFailures, OutputAll:
+		return p, nil
+	default:
+		return "", fmt.Errorf("invalid output policy '%s', must be one of: first, failures, all", name)
+	}
 }
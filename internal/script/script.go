@@ -0,0 +1,228 @@
+package script
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/dop251/goja"
+	"github.com/fdrolshagen/jetter/internal"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Response is the part of an HTTP response that is exposed to handler scripts
+// through the IntelliJ-compatible `response` object. Events holds the server-sent
+// events of an event stream, which are exposed as `response.events`.
+type Response struct {
+	Status  int
+	Headers http.Header
+	Body    []byte
+	Events  []Event
+}
+
+// Event is a server-sent event. Its data is exposed parsed if it is JSON.
+type Event struct {
+	ID   string
+	Type string
+	Data string
+}
+
+var programs sync.Map
+
+// Run executes an IntelliJ response handler script against the given response.
+//
+// The script has access to the `client` object (client.global, client.test,
+// client.assert and client.log) and the `response` object (status, body, headers,
+// contentType and, for event streams, events). Variables stored with client.global.set are written to globals,
+// so they can be used by subsequent requests.
+//
+// The returned test results contain one entry per client.test call. An error is
+// returned if the script cannot be compiled, throws outside of a test, or is
+// interrupted because ctx is done.
+func Run(ctx context.Context, source string, resp Response, globals map[string]string) ([]internal.TestResult, error) {
+	program, err := compile(source)
+	if err != nil {
+		return nil, err
+	}
+
+	vm := goja.New()
+	var tests []internal.TestResult
+
+	if err := vm.Set("client", newClient(vm, globals, &tests)); err != nil {
+		return nil, err
+	}
+	if err := vm.Set("response", newResponse(vm, resp)); err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			vm.Interrupt(ctx.Err())
+		case <-done:
+		}
+	}()
+
+	if _, err := vm.RunProgram(program); err != nil {
+		return tests, fmt.Errorf("response handler failed: %s", errorMessage(err))
+	}
+	return tests, nil
+}
+
+func compile(source string) (*goja.Program, error) {
+	if p, ok := programs.Load(source); ok {
+		return p.(*goja.Program), nil
+	}
+
+	p, err := goja.Compile("response-handler.js", source, false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid response handler: %w", err)
+	}
+	programs.Store(source, p)
+	return p, nil
+}
+
+func newClient(vm *goja.Runtime, globals map[string]string, tests *[]internal.TestResult) *goja.Object {
+	global := vm.NewObject()
+	_ = global.Set("set", func(name string, value goja.Value) {
+		globals[name] = value.String()
+	})
+	_ = global.Set("get", func(name string) goja.Value {
+		if v, ok := globals[name]; ok {
+			return vm.ToValue(v)
+		}
+		return goja.Null()
+	})
+	_ = global.Set("isEmpty", func() bool {
+		return len(globals) == 0
+	})
+	_ = global.Set("clear", func(name string) {
+		delete(globals, name)
+	})
+	_ = global.Set("clearAll", func() {
+		for k := range globals {
+			delete(globals, k)
+		}
+	})
+
+	client := vm.NewObject()
+	_ = client.Set("global", global)
+	_ = client.Set("test", func(name string, fn goja.Value) {
+		callable, ok := goja.AssertFunction(fn)
+		if !ok {
+			panic(vm.NewTypeError("client.test: second argument must be a function"))
+		}
+
+		result := internal.TestResult{Name: name, Passed: true}
+		if _, err := callable(goja.Undefined()); err != nil {
+			if _, interrupted := err.(*goja.InterruptedError); interrupted {
+				panic(err)
+			}
+			result.Passed = false
+			result.Message = errorMessage(err)
+		}
+		*tests = append(*tests, result)
+	})
+	_ = client.Set("assert", func(condition bool, message string) {
+		if condition {
+			return
+		}
+		if message == "" {
+			message = "assertion failed"
+		}
+		panic(newError(vm, message))
+	})
+	// client.log is accepted for compatibility, but output is discarded
+	// as it would interleave with thousands of concurrent executions.
+	_ = client.Set("log", func(goja.FunctionCall) goja.Value {
+		return goja.Undefined()
+	})
+
+	return client
+}
+
+func newResponse(vm *goja.Runtime, resp Response) *goja.Object {
+	mimeType, params, _ := mime.ParseMediaType(resp.Headers.Get("Content-Type"))
+
+	contentType := vm.NewObject()
+	_ = contentType.Set("mimeType", mimeType)
+	_ = contentType.Set("charset", params["charset"])
+
+	headers := vm.NewObject()
+	_ = headers.Set("valueOf", func(name string) goja.Value {
+		if v := resp.Headers.Values(name); len(v) > 0 {
+			return vm.ToValue(v[0])
+		}
+		return goja.Null()
+	})
+	_ = headers.Set("valuesOf", func(name string) []string {
+		v := resp.Headers.Values(name)
+		if v == nil {
+			return []string{}
+		}
+		return v
+	})
+
+	response := vm.NewObject()
+	_ = response.Set("status", resp.Status)
+	_ = response.Set("body", responseBody(vm, mimeType, resp.Body))
+	_ = response.Set("headers", headers)
+	_ = response.Set("contentType", contentType)
+	if resp.Events != nil {
+		_ = response.Set("events", newEvents(vm, resp.Events))
+	}
+	return response
+}
+
+// newEvents returns the events as array of `{id, event, data}` objects.
+func newEvents(vm *goja.Runtime, events []Event) []any {
+	result := make([]any, len(events))
+	for i, e := range events {
+		event := vm.NewObject()
+		_ = event.Set("id", e.ID)
+		_ = event.Set("event", e.Type)
+		_ = event.Set("data", responseBody(vm, "json", []byte(e.Data)))
+		result[i] = event
+	}
+	return result
+}
+
+// responseBody returns the parsed body for JSON responses, as IntelliJ does,
+// and the raw body as string for everything else.
+func responseBody(vm *goja.Runtime, mimeType string, body []byte) goja.Value {
+	if strings.Contains(mimeType, "json") {
+		var v any
+		if err := json.Unmarshal(body, &v); err == nil {
+			return vm.ToValue(v)
+		}
+	}
+	return vm.ToValue(string(body))
+}
+
+func newError(vm *goja.Runtime, message string) goja.Value {
+	ctor, ok := goja.AssertConstructor(vm.Get("Error"))
+	if !ok {
+		return vm.ToValue(message)
+	}
+	obj, err := ctor(nil, vm.ToValue(message))
+	if err != nil {
+		return vm.ToValue(message)
+	}
+	return obj
+}
+
+func errorMessage(err error) string {
+	if ex, ok := err.(*goja.Exception); ok {
+		if obj, ok := ex.Value().(*goja.Object); ok {
+			if msg := obj.Get("message"); msg != nil && !goja.IsUndefined(msg) {
+				return msg.String()
+			}
+		}
+		return ex.Value().String()
+	}
+	return err.Error()
+}
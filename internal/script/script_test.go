@@ -0,0 +1,135 @@
+package script
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func jsonResponse(status int, body string) Response {
+	return Response{
+		Status:  status,
+		Headers: http.Header{"Content-Type": []string{"application/json; charset=utf-8"}},
+		Body:    []byte(body),
+	}
+}
+
+func TestRun_SetsGlobalsFromJsonBody(t *testing.T) {
+	globals := map[string]string{}
+	src := `client.global.set("token", response.body.token);`
+
+	tests, err := Run(context.Background(), src, jsonResponse(200, `{"token":"abc"}`), globals)
+
+	assert.Nil(t, err)
+	assert.Empty(t, tests)
+	assert.Equal(t, "abc", globals["token"])
+}
+
+func TestRun_CollectsTestResults(t *testing.T) {
+	src := `
+		client.test("status is 200", function() {
+			client.assert(response.status === 200, "unexpected status");
+		});
+		client.test("has id", function() {
+			client.assert(response.body.id !== undefined, "id missing");
+		});
+	`
+
+	tests, err := Run(context.Background(), src, jsonResponse(200, `{"name":"foo"}`), map[string]string{})
+
+	assert.Nil(t, err)
+	assert.Len(t, tests, 2)
+	assert.Equal(t, "status is 200", tests[0].Name)
+	assert.True(t, tests[0].Passed)
+	assert.Equal(t, "has id", tests[1].Name)
+	assert.False(t, tests[1].Passed)
+	assert.Equal(t, "id missing", tests[1].Message)
+}
+
+func TestRun_ExposesHeadersAndContentType(t *testing.T) {
+	globals := map[string]string{}
+	resp := jsonResponse(201, `{}`)
+	resp.Headers.Add("Location", "/users/1")
+	src := `
+		client.global.set("location", response.headers.valueOf("location"));
+		client.global.set("mime", response.contentType.mimeType);
+		client.global.set("charset", response.contentType.charset);
+	`
+
+	_, err := Run(context.Background(), src, resp, globals)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "/users/1", globals["location"])
+	assert.Equal(t, "application/json", globals["mime"])
+	assert.Equal(t, "utf-8", globals["charset"])
+}
+
+func TestRun_PlainTextBodyIsString(t *testing.T) {
+	globals := map[string]string{}
+	resp := Response{Status: 200, Headers: http.Header{}, Body: []byte("pong")}
+
+	_, err := Run(context.Background(), `client.global.set("body", response.body);`, resp, globals)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "pong", globals["body"])
+}
+
+func TestRun_ExposesEvents(t *testing.T) {
+	globals := map[string]string{}
+	resp := Response{
+		Status:  200,
+		Headers: http.Header{"Content-Type": []string{"text/event-stream"}},
+		Events: []Event{
+			{ID: "1", Type: "order", Data: `{"orderId": "A-1"}`},
+			{ID: "1", Type: "message", Data: "plain"},
+		},
+	}
+	src := `
+		client.global.set("count", response.events.length);
+		client.global.set("orderId", response.events[0].data.orderId);
+		client.global.set("last", response.events[1].id + " " + response.events[1].event + " " + response.events[1].data);
+	`
+
+	_, err := Run(context.Background(), src, resp, globals)
+
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"count": "2", "orderId": "A-1", "last": "1 message plain"}, globals)
+}
+
+func TestRun_GlobalGetAndClear(t *testing.T) {
+	globals := map[string]string{"a": "1", "b": "2"}
+	src := `
+		client.global.set("c", client.global.get("a") + "!");
+		client.global.clear("b");
+	`
+
+	_, err := Run(context.Background(), src, jsonResponse(200, `{}`), globals)
+
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"a": "1", "c": "1!"}, globals)
+}
+
+func TestRun_ErrorOnAssertOutsideOfTest(t *testing.T) {
+	_, err := Run(context.Background(), `client.assert(false, "boom");`, jsonResponse(500, `{}`), map[string]string{})
+
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "boom")
+}
+
+func TestRun_ErrorOnSyntaxError(t *testing.T) {
+	_, err := Run(context.Background(), `client.global.set(`, jsonResponse(200, `{}`), map[string]string{})
+
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "invalid response handler")
+}
+
+func TestRun_InterruptedByContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := Run(ctx, `while (true) {}`, jsonResponse(200, `{}`), map[string]string{})
+
+	assert.NotNil(t, err)
+}
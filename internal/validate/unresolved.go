@@ -0,0 +1,125 @@
+package validate
+
+import (
+	"fmt"
+	"github.com/fdrolshagen/jetter/internal"
+	"os"
+	"strings"
+)
+
+// Unresolved reports the placeholders left in the requests of c once their variables are
+// substituted. evaluated are the requests of c as returned by executor.Evaluate. Files
+// referenced with '<' are checked as well, files sent raw with '<@' are not. Variables
+// set by client.global.set in a response handler are only known at runtime and dynamic
+// variables are evaluated while sending, so neither of them is reported.
+func Unresolved(c internal.Collection, evaluated []internal.Request) internal.Diagnostics {
+	globals := globalVariables(c.Requests)
+
+	var diagnostics internal.Diagnostics
+	for i, r := range evaluated {
+		// Files are templated while sending, so their placeholders are resolved if the
+		// request's scope defines them.
+		scope := requestScope(c, i)
+		reported := make(map[string]bool)
+		// check reports the placeholders within s, which starts at the given line of file.
+		// If s is a part of that text, such as a multipart part, offset is false and the
+		// placeholders are located at the given line.
+		check := func(s, where, file string, line int, offset bool, skip map[string]bool) {
+			for _, loc := range placeholderRegex.FindAllStringSubmatchIndex(s, -1) {
+				placeholder, trimmed := s[loc[0]:loc[1]], strings.TrimSpace(s[loc[2]:loc[3]])
+				name, isRef := internal.ReferenceName(placeholder)
+				if strings.HasPrefix(trimmed, "$") || (isRef && (globals[name] || skip[name])) || reported[placeholder+where] {
+					continue
+				}
+				reported[placeholder+where] = true
+
+				d := internal.Diagnostic{
+					Severity: internal.SeverityError,
+					File:     file,
+					Line:     line,
+					Column:   1,
+					Code:     CodeUnresolvedVariable,
+					Message:  fmt.Sprintf("unresolved variable '%s' in %s of request '%s'", placeholder, where, r.Name),
+					Fix: fmt.Sprintf("define it with '@%s = value' or in the environment file, "+
+						"or pass --allow-unresolved to send it as is", name),
+				}
+				if !isRef && trimmed != "" {
+					d.Message = fmt.Sprintf("'%s' in %s of request '%s' is not substituted, as the variable name is surrounded by spaces",
+						placeholder, where, r.Name)
+					d.Fix = fmt.Sprintf("write it as '{{%s}}', or pass --allow-unresolved to send it as is", trimmed)
+				}
+				if offset {
+					before := s[:loc[0]]
+					d.Line += strings.Count(before, "\n")
+					d.Column = loc[0] - strings.LastIndex(before, "\n")
+				}
+				diagnostics = append(diagnostics, d)
+			}
+		}
+		checkFile := func(f *internal.BodyFile, where string) {
+			if f == nil || f.Raw {
+				return
+			}
+			content, err := os.ReadFile(f.Path)
+			if err != nil {
+				return
+			}
+			check(string(content), fmt.Sprintf(where, f.Path), f.Path, 1, true, scope)
+		}
+
+		headerLine := func(key string) int {
+			if line, ok := r.HeaderLines[key]; ok {
+				return line
+			}
+			return r.Line
+		}
+		bodyLine := r.BodyLine
+		if bodyLine == 0 {
+			bodyLine = r.Line
+		}
+
+		check(r.Url, "URL", r.File, r.Line, false, nil)
+		for _, key := range sortedKeys(r.Headers) {
+			check(r.Headers[key], fmt.Sprintf("header '%s'", key), r.File, headerLine(key), false, nil)
+		}
+		check(r.Body, "body", r.File, bodyLine, true, nil)
+		checkFile(r.BodyFile, "body file '%s'")
+		if r.Multipart != nil {
+			for i, part := range r.Multipart.Parts {
+				where := fmt.Sprintf("multipart part %d", i+1)
+				for _, key := range sortedKeys(part.Headers) {
+					check(part.Headers[key], where, r.File, bodyLine, false, nil)
+				}
+				check(part.Content, where, r.File, bodyLine, false, nil)
+				checkFile(part.File, "file '%s' of "+where)
+			}
+		}
+		if r.GraphQL != nil {
+			check(r.GraphQL.Query, "GraphQL query", r.File, bodyLine, true, nil)
+			check(r.GraphQL.Variables, "GraphQL variables", r.File, bodyLine, false, nil)
+		}
+		if r.WebSocket != nil {
+			for i, step := range r.WebSocket.Steps {
+				check(step.Message, fmt.Sprintf("WebSocket step %d", i+1), r.File, bodyLine, false, nil)
+			}
+		}
+	}
+	return diagnostics
+}
+
+// requestScope returns the names of the variables known to the request at index i.
+func requestScope(c internal.Collection, i int) map[string]bool {
+	scope := make(map[string]bool)
+	for name := range c.Variables {
+		scope[name] = true
+	}
+	for name := range internal.ScopeAt(c.ScopedVariables, i) {
+		scope[name] = true
+	}
+	if i < len(c.Requests) {
+		for name := range c.Requests[i].Variables {
+			scope[name] = true
+		}
+	}
+	return scope
+}
@@ -0,0 +1,119 @@
+package validate
+
+import (
+	"github.com/fdrolshagen/jetter/internal"
+	"github.com/fdrolshagen/jetter/internal/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUnresolved(t *testing.T) {
+	c := parse(t, strings.Join([]string{
+		"@URL = http://localhost",
+		"@ID = {{$random.uuid()}}",
+		"",
+		"### Login",
+		"POST {{URL}}/login",
+		"",
+		"> {% client.global.set(\"token\", response.body.token); %}",
+		"",
+		"### Get User",
+		"GET {{URL}}/users/{{ID}}/{{NAME}}?again={{NAME}}",
+		"Authorization: Bearer {{token}}",
+		"X-Tenant: {{TENANT}}",
+		"",
+		"{\"name\": \"{{NAME}}\"}",
+	}, "\n"))
+	requests, err := executor.Evaluate(&c)
+	require.NoError(t, err)
+
+	diagnostics := Unresolved(c, requests)
+
+	assert.Equal(t, []string{CodeUnresolvedVariable, CodeUnresolvedVariable, CodeUnresolvedVariable}, codes(diagnostics))
+	assert.Equal(t, internal.Diagnostic{
+		Severity: internal.SeverityError,
+		Line:     10,
+		Column:   1,
+		Code:     CodeUnresolvedVariable,
+		Message:  "unresolved variable '{{NAME}}' in URL of request 'Get User'",
+		Fix:      "define it with '@NAME = value' or in the environment file, or pass --allow-unresolved to send it as is",
+	}, diagnostics[0])
+	assert.Equal(t, "unresolved variable '{{TENANT}}' in header 'X-Tenant' of request 'Get User'", diagnostics[1].Message)
+	assert.Equal(t, "unresolved variable '{{NAME}}' in body of request 'Get User'", diagnostics[2].Message)
+}
+
+func TestUnresolved_ChecksTemplatedFiles(t *testing.T) {
+	dir := t.TempDir()
+	body := filepath.Join(dir, "body.json")
+	raw := filepath.Join(dir, "raw.bin")
+	part := filepath.Join(dir, "part.json")
+	require.NoError(t, os.WriteFile(body, []byte("{\n  \"id\": \"{{ID}}\",\n  \"name\": \"{{NAME}}\",\n  \"at\": \"{{$timestamp}}\"\n}"), 0644))
+	require.NoError(t, os.WriteFile(raw, []byte(`{{NAME}}`), 0644))
+	require.NoError(t, os.WriteFile(part, []byte(`{"tenant": "{{TENANT}}"}`), 0644))
+	c := parse(t, strings.Join([]string{
+		"@ID = 1",
+		"",
+		"### Body",
+		"POST http://localhost/users",
+		"",
+		"< " + body,
+		"",
+		"### Raw",
+		"POST http://localhost/users",
+		"",
+		"<@ " + raw,
+		"",
+		"### Upload",
+		"POST http://localhost/upload",
+		"Content-Type: multipart/form-data; boundary=b",
+		"",
+		"--b",
+		"Content-Disposition: form-data; name=\"data\"; filename=\"part.json\"",
+		"",
+		"< " + part,
+		"--b--",
+	}, "\n"))
+	requests, err := executor.Evaluate(&c)
+	require.NoError(t, err)
+
+	diagnostics := Unresolved(c, requests)
+
+	require.Len(t, diagnostics, 2)
+	assert.Equal(t, "unresolved variable '{{NAME}}' in body file '"+body+"' of request 'Body'", diagnostics[0].Message)
+	assert.Equal(t, body, diagnostics[0].File)
+	assert.Equal(t, 3, diagnostics[0].Line)
+	assert.Equal(t, 12, diagnostics[0].Column)
+	assert.Equal(t, "unresolved variable '{{TENANT}}' in file '"+part+"' of multipart part 1 of request 'Upload'", diagnostics[1].Message)
+}
+
+func TestUnresolved_ReportsSpacesAndPositions(t *testing.T) {
+	c := parse(t, strings.Join([]string{
+		"@tok = abc",
+		"",
+		"### Get User",
+		"POST http://localhost/users",
+		"Authorization: Bearer {{ tok }}",
+		"X-Tenant: {{TENANT}}",
+		"",
+		"{",
+		"  \"name\": \"{{NAME}}\"",
+		"}",
+	}, "\n"))
+	requests, err := executor.Evaluate(&c)
+	require.NoError(t, err)
+
+	diagnostics := Unresolved(c, requests)
+
+	require.Len(t, diagnostics, 3)
+	assert.Equal(t, "'{{ tok }}' in header 'Authorization' of request 'Get User' is not substituted, as the variable name is surrounded by spaces", diagnostics[0].Message)
+	assert.Equal(t, "write it as '{{tok}}', or pass --allow-unresolved to send it as is", diagnostics[0].Fix)
+	assert.Equal(t, 5, diagnostics[0].Line)
+	assert.Equal(t, 6, diagnostics[1].Line)
+	assert.Equal(t, "unresolved variable '{{NAME}}' in body of request 'Get User'", diagnostics[2].Message)
+	assert.Equal(t, 9, diagnostics[2].Line)
+	assert.Equal(t, 12, diagnostics[2].Column)
+}
@@ -0,0 +1,349 @@
+package validate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/fdrolshagen/jetter/internal"
+	"maps"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Diagnostic codes reported by Validate.
+const (
+	CodeUnresolvedVariable = "unresolved-variable"
+	CodeUnknownFunction    = "unknown-function"
+	CodeUnknownAuth        = "unknown-auth"
+	CodeDuplicateName      = "duplicate-request-name"
+	CodeInvalidUrl         = "invalid-url"
+	CodeInvalidGraphQL     = "invalid-graphql"
+	CodeVariableCycle      = "variable-cycle"
+)
+
+var (
+	placeholderRegex = regexp.MustCompile(`\{\{([^{}]*)}}`)
+	globalSetRegex   = regexp.MustCompile(`client\.global\.set\(\s*["'` + "`" + `]([^"'` + "`" + `]+)["'` + "`" + `]`)
+)
+
+// Validate checks the collection for problems that would otherwise only show up while
+// running it. env is the selected environment, or nil if there is none.
+func Validate(c internal.Collection, env *internal.Environment) internal.Diagnostics {
+	v := validator{collection: c, env: env, defined: definedVariables(c, env)}
+	v.checkVariables()
+	v.checkRequests()
+	return v.diagnostics
+}
+
+type validator struct {
+	collection  internal.Collection
+	env         *internal.Environment
+	defined     map[string]bool
+	diagnostics internal.Diagnostics
+}
+
+// definedVariables returns the names of all variables that are available to the requests,
+// including the globals set by response handler scripts.
+func definedVariables(c internal.Collection, env *internal.Environment) map[string]bool {
+	defined := make(map[string]bool)
+	for k := range c.Variables {
+		defined[k] = true
+	}
+	if env != nil {
+		for k := range env.Variables {
+			defined[k] = true
+		}
+	}
+	for name := range globalVariables(c.Requests) {
+		defined[name] = true
+	}
+	return defined
+}
+
+// globalVariables returns the names of the globals set by the response handler scripts.
+func globalVariables(requests []internal.Request) map[string]bool {
+	globals := make(map[string]bool)
+	for _, r := range requests {
+		for _, m := range globalSetRegex.FindAllStringSubmatch(r.ResponseHandler, -1) {
+			globals[m[1]] = true
+		}
+	}
+	return globals
+}
+
+func (v *validator) checkVariables() {
+	names := make([]string, 0, len(v.collection.Variables))
+	for k := range v.collection.Variables {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		pos := v.collection.VariablePositions[name]
+		where := fmt.Sprintf("variable '%s'", name)
+		v.checkPlaceholders(v.collection.Variables[name], where, pos, nil, false)
+	}
+	v.checkCycles()
+	for _, scoped := range v.collection.ScopedVariables {
+		where := fmt.Sprintf("variable '%s'", scoped.Name)
+		v.checkPlaceholders(scoped.Value, where, scoped.Position, internal.ScopeAt(v.collection.ScopedVariables, scoped.Request), false)
+	}
+}
+
+// checkCycles reports collection and environment variables that reference each other.
+func (v *validator) checkCycles() {
+	vars := make(map[string]string, len(v.collection.Variables))
+	if v.env != nil {
+		maps.Copy(vars, v.env.Variables)
+	}
+	maps.Copy(vars, v.collection.Variables)
+
+	var cycle *internal.CycleError
+	if _, err := internal.ResolveVariables(vars); errors.As(err, &cycle) {
+		v.report(v.collection.VariablePositions[cycle.Chain[0]], CodeVariableCycle, cycle.Error(),
+			"give one of the variables a value that does not refer back to the others")
+	}
+}
+
+func (v *validator) checkRequests() {
+	first := make(map[string]internal.Request)
+	for i, r := range v.collection.Requests {
+		pos := internal.Position{File: r.File, Line: r.Line}
+		// vars are the variables declared for this request only, by scope or run statement.
+		vars := internal.ScopeAt(v.collection.ScopedVariables, i)
+		for k, value := range r.Variables {
+			vars[k] = value
+		}
+
+		if other, ok := first[r.Name]; ok && (other.File != r.File || other.Line != r.Line) {
+			v.report(pos, CodeDuplicateName, fmt.Sprintf("request name '%s' is already used at line %d", r.Name, other.Line),
+				"give each request a unique name, otherwise 'run #name' and the report cannot tell them apart")
+		} else if !ok {
+			first[r.Name] = r
+		}
+
+		if v.checkPlaceholders(r.Url, "URL", pos, vars, true) {
+			v.checkUrl(r, vars, pos)
+		}
+		for _, key := range sortedKeys(r.Headers) {
+			v.checkPlaceholders(r.Headers[key], fmt.Sprintf("header '%s'", key), pos, vars, true)
+		}
+		v.checkPlaceholders(r.Body, "body", pos, vars, true)
+		if r.WebSocket != nil {
+			for i, step := range r.WebSocket.Steps {
+				v.checkPlaceholders(step.Message, fmt.Sprintf("WebSocket step %d", i+1), pos, vars, true)
+			}
+		}
+		if r.Multipart != nil {
+			for i, part := range r.Multipart.Parts {
+				where := fmt.Sprintf("multipart part %d", i+1)
+				for _, key := range sortedKeys(part.Headers) {
+					v.checkPlaceholders(part.Headers[key], where, pos, vars, true)
+				}
+				v.checkPlaceholders(part.Content, where, pos, vars, true)
+			}
+		}
+		if r.GraphQL != nil {
+			v.checkGraphQL(r, vars, pos)
+		}
+	}
+}
+
+// checkGraphQL reports GraphQL requests without query and variables that are no JSON object.
+// Variables with placeholders are only checked for unresolved placeholders, as their values
+// decide whether the JSON is valid.
+func (v *validator) checkGraphQL(r internal.Request, vars map[string]string, pos internal.Position) {
+	if r.GraphQL.Query == "" {
+		v.report(pos, CodeInvalidGraphQL, "missing GraphQL query", "add the query after the headers and a blank line")
+	}
+	v.checkPlaceholders(r.GraphQL.Query, "GraphQL query", pos, vars, true)
+
+	variables := r.GraphQL.Variables
+	if v.checkPlaceholders(variables, "GraphQL variables", pos, vars, true) && variables != "" && !placeholderRegex.MatchString(variables) {
+		var obj map[string]any
+		if err := json.Unmarshal([]byte(variables), &obj); err != nil {
+			v.report(pos, CodeInvalidGraphQL, fmt.Sprintf("invalid GraphQL variables: %v", err),
+				"give the variables as JSON object after the query, e.g. '{\"id\": 1}'")
+		}
+	}
+}
+
+// checkPlaceholders reports the placeholders within s that cannot be resolved. It reports
+// whether all placeholders are resolvable variables, so that s can be checked further.
+func (v *validator) checkPlaceholders(s, where string, pos internal.Position, overrides map[string]string, inRequest bool) bool {
+	resolved := true
+	for _, m := range placeholderRegex.FindAllStringSubmatch(s, -1) {
+		trimmed := strings.TrimSpace(m[1])
+		if !strings.HasPrefix(trimmed, "$") {
+			name, isRef := internal.ReferenceName(m[0])
+			if !isRef && trimmed != "" {
+				v.report(pos, CodeUnresolvedVariable, fmt.Sprintf("'%s' in %s is not substituted, as the variable name is surrounded by spaces", m[0], where),
+					fmt.Sprintf("write it as '{{%s}}'", trimmed))
+				resolved = false
+				continue
+			}
+			if _, overridden := overrides[name]; !v.defined[name] && !overridden {
+				v.report(pos, CodeUnresolvedVariable, fmt.Sprintf("unresolved variable '{{%s}}' in %s", name, where),
+					fmt.Sprintf("define it with '@%s = value', in the environment file, or by client.global.set in a response handler", name))
+				resolved = false
+			}
+			continue
+		}
+
+		resolved = false
+		calls := internal.FindFunctionCalls(m[0])
+		if len(calls) == 0 {
+			v.report(pos, CodeUnknownFunction, fmt.Sprintf("'%s' in %s is not a dynamic variable", m[0], where),
+				"use a dynamic variable like '{{$uuid}}' or '{{$random.integer(1, 10)}}'")
+			continue
+		}
+		v.checkFunction(calls[0], m[0], where, pos, inRequest)
+	}
+	return resolved
+}
+
+func (v *validator) checkFunction(call internal.FunctionCall, placeholder, where string, pos internal.Position, inRequest bool) {
+	if call.Namespace == "auth" && inRequest {
+		if call.Name != "token" {
+			v.report(pos, CodeUnknownFunction, fmt.Sprintf("unknown function '%s' in %s", placeholder, where),
+				`use '{{$auth.token("auth-id")}}'`)
+			return
+		}
+		id := strings.Trim(call.Arg, `"`)
+		if v.env == nil {
+			v.report(pos, CodeUnknownAuth, fmt.Sprintf("auth '%s' in %s cannot be resolved without an environment", id, where),
+				"select an environment with --env")
+			return
+		}
+		if _, ok := v.env.Security.Auth[id]; !ok {
+			v.report(pos, CodeUnknownAuth, fmt.Sprintf("auth '%s' in %s is not defined in the environment", id, where),
+				fmt.Sprintf("add '%s' to Security.Auth of the environment", id))
+		}
+		return
+	}
+
+	if _, err := internal.CallFunction(call, nil); err != nil {
+		v.report(pos, CodeUnknownFunction, fmt.Sprintf("invalid function '%s' in %s: %v", placeholder, where, err),
+			"use one of the supported dynamic variables, e.g. '{{$uuid}}'")
+	}
+}
+
+// checkUrl reports request URLs that are not absolute http or https URLs, ws or wss URLs
+// for WebSocket requests, or gRPC targets for gRPC requests, once their variables are
+// substituted. Relative URLs are checked joined with their base URL. URLs depending on
+// globals are skipped, as those are only known at runtime.
+func (v *validator) checkUrl(r internal.Request, vars map[string]string, pos internal.Position) {
+	raw, known := v.substitute(r.Url, vars)
+	if !known {
+		return
+	}
+	if r.GRPC == nil && internal.IsRelativeURL(raw) {
+		base := v.collection.Config.BaseURL
+		if base == "" {
+			base, _ = v.lookup(internal.BaseURLVariable, vars)
+		}
+		if base == "" {
+			v.report(pos, CodeInvalidUrl, fmt.Sprintf("relative URL '%s' without base URL", raw),
+				fmt.Sprintf("set --base-url, '#@jetter base-url' or the '%s' variable", internal.BaseURLVariable))
+			return
+		}
+		if base, known = v.substitute(base, vars); !known {
+			return
+		}
+		if rest, ok := strings.CutPrefix(base, "http"); ok && r.WebSocket != nil {
+			base = "ws" + rest
+		}
+		raw = internal.ResolveURL(base, raw)
+	}
+	if r.GRPC != nil {
+		if _, err := internal.ParseGRPCTarget(raw); err != nil {
+			v.report(pos, CodeInvalidUrl, fmt.Sprintf("invalid gRPC target '%s': %v", raw, err),
+				"use '<host>:<port>/<package.Service>/<Method>'")
+		}
+		return
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		v.report(pos, CodeInvalidUrl, fmt.Sprintf("invalid URL '%s': %v", raw, err), "check the URL of the request line")
+		return
+	}
+	if r.WebSocket != nil && u.Scheme != "ws" && u.Scheme != "wss" {
+		v.report(pos, CodeInvalidUrl, fmt.Sprintf("invalid URL '%s': scheme must be ws or wss", raw),
+			"start the URL with ws:// or wss://")
+		return
+	}
+	if r.WebSocket == nil && u.Scheme != "http" && u.Scheme != "https" {
+		v.report(pos, CodeInvalidUrl, fmt.Sprintf("invalid URL '%s': scheme must be http or https", raw),
+			"start the URL with http:// or https://")
+		return
+	}
+	if u.Host == "" {
+		v.report(pos, CodeInvalidUrl, fmt.Sprintf("invalid URL '%s': missing host", raw), "add a host to the URL")
+	}
+}
+
+// substitute replaces the variables within s, including the variables their values refer
+// to. It reports whether all of them are known.
+func (v *validator) substitute(s string, vars map[string]string) (string, bool) {
+	return v.substituteWithout(s, vars, nil)
+}
+
+// substituteWithout substitutes like substitute. The variables in seen are currently being
+// substituted, references to them are part of a cycle and treated as unknown.
+func (v *validator) substituteWithout(s string, vars map[string]string, seen []string) (string, bool) {
+	known := true
+	result := placeholderRegex.ReplaceAllStringFunc(s, func(p string) string {
+		name, isRef := internal.ReferenceName(p)
+		value, ok := v.lookup(name, vars)
+		if isRef && ok && !slices.Contains(seen, name) {
+			if value, ok = v.substituteWithout(value, vars, append(seen, name)); ok {
+				return value
+			}
+		}
+		known = false
+		return p
+	})
+	return result, known
+}
+
+// lookup returns the value of the variable with the given name from vars, the collection
+// or the environment.
+func (v *validator) lookup(name string, vars map[string]string) (string, bool) {
+	if value, ok := vars[name]; ok {
+		return value, true
+	}
+	if value, ok := v.collection.Variables[name]; ok {
+		return value, true
+	}
+	if v.env != nil {
+		if value, ok := v.env.Variables[name]; ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+func (v *validator) report(pos internal.Position, code, message, fix string) {
+	v.diagnostics = append(v.diagnostics, internal.Diagnostic{
+		Severity: internal.SeverityError,
+		File:     pos.File,
+		Line:     pos.Line,
+		Column:   1,
+		Code:     code,
+		Message:  message,
+		Fix:      fix,
+	})
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,320 @@
+package validate
+
+import (
+	"github.com/fdrolshagen/jetter/internal"
+	"github.com/fdrolshagen/jetter/internal/executor"
+	"github.com/fdrolshagen/jetter/internal/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func parse(t *testing.T, content string) internal.Collection {
+	t.Helper()
+	c, err := parser.ParseHttp(strings.NewReader(content))
+	require.NoError(t, err)
+	return c
+}
+
+func codes(diagnostics internal.Diagnostics) []string {
+	var result []string
+	for _, d := range diagnostics {
+		result = append(result, d.Code)
+	}
+	return result
+}
+
+func TestValidate_ShouldAcceptValidCollection(t *testing.T) {
+	c := parse(t, strings.Join([]string{
+		"@ID = 0{{$random.hexadecimal(12)}}",
+		"@USER = {{$uuid}}-{{$random.email}}-{{ $random.integer(1, 10) }}-{{$timestamp}}",
+		"",
+		"### Login",
+		"POST {{URL}}/login",
+		"",
+		`> {% client.global.set("token", response.body.token); %}`,
+		"",
+		"### Get User",
+		"GET {{URL}}/users/{{ID}}",
+		"Authorization: Bearer {{token}}",
+		"",
+		"### Get Profile",
+		"GET {{URL}}/profile",
+		`Authorization: Bearer {{$auth.token("auth-id")}}`,
+		"",
+		"###",
+		"run #Login",
+		"run #Get User (@ID=42)",
+	}, "\n"))
+	env := &internal.Environment{
+		Variables: map[string]string{"URL": "http://localhost:8081"},
+		Security:  internal.Security{Auth: internal.AuthMap{"auth-id": {}}},
+	}
+
+	assert.Empty(t, Validate(c, env))
+}
+
+func TestValidate_ShouldReportProblems(t *testing.T) {
+	c := parse(t, strings.Join([]string{
+		"@ID = {{$uuid.random()}}",
+		"@NAME = {{FIRST}} {{LAST}}",
+		"",
+		"### Users",
+		"GET {{URL}}/users",
+		`Authorization: Bearer {{$auth.token("missing")}}`,
+		"",
+		"### Users",
+		"POST ftp://localhost/users",
+		"Content-Type: application/json",
+		"",
+		`{"id": "{{$random.uuid.v4}}"}`,
+		"",
+		"### Relative",
+		"GET http:///users",
+	}, "\n"))
+	env := &internal.Environment{Variables: map[string]string{"FIRST": "Jane"}}
+
+	diagnostics := Validate(c, env)
+
+	assert.Equal(t, []string{
+		CodeUnknownFunction,
+		CodeUnresolvedVariable,
+		CodeUnresolvedVariable,
+		CodeUnknownAuth,
+		CodeDuplicateName,
+		CodeInvalidUrl,
+		CodeUnknownFunction,
+		CodeInvalidUrl,
+	}, codes(diagnostics))
+	assert.Equal(t, internal.Diagnostic{
+		Severity: internal.SeverityError,
+		Line:     2,
+		Column:   1,
+		Code:     CodeUnresolvedVariable,
+		Message:  "unresolved variable '{{LAST}}' in variable 'NAME'",
+		Fix:      "define it with '@LAST = value', in the environment file, or by client.global.set in a response handler",
+	}, diagnostics[1])
+	assert.Equal(t, 5, diagnostics[2].Line)
+	assert.Contains(t, diagnostics[2].Message, "'{{URL}}' in URL")
+	assert.Equal(t, "request name 'Users' is already used at line 5", diagnostics[4].Message)
+	assert.Equal(t, 9, diagnostics[4].Line)
+	assert.Contains(t, diagnostics[5].Message, "scheme must be http or https")
+	assert.Contains(t, diagnostics[6].Message, "'{{$random.uuid.v4}}' in body is not a dynamic variable")
+	assert.Contains(t, diagnostics[7].Message, "missing host")
+}
+
+func TestValidate_ShouldReportAuthWithoutEnvironment(t *testing.T) {
+	c := parse(t, "###\nGET http://localhost/users\nAuthorization: Bearer {{$auth.token(\"auth-id\")}}")
+
+	diagnostics := Validate(c, nil)
+
+	assert.Equal(t, []string{CodeUnknownAuth}, codes(diagnostics))
+	assert.Equal(t, "select an environment with --env", diagnostics[0].Fix)
+}
+
+func TestValidate_ShouldNotReportRunRequestsAsDuplicates(t *testing.T) {
+	c := parse(t, "### Ping\nGET http://localhost/ping\n\n###\nrun #Ping\nrun #Ping")
+
+	assert.Empty(t, Validate(c, nil))
+}
+
+func TestValidate_ShouldReportGraphQLProblems(t *testing.T) {
+	c := parse(t, strings.Join([]string{
+		"### Empty",
+		"GRAPHQL http://localhost/graphql",
+		"",
+		"### Invalid Variables",
+		"GRAPHQL http://localhost/graphql",
+		"",
+		"query User($id: ID!) { user(id: $id) { name } }",
+		"",
+		`{"id": }`,
+		"",
+		"### Unresolved",
+		"GRAPHQL http://localhost/graphql",
+		"",
+		"query User($id: ID!) { user(id: $id) { {{FIELD}} } }",
+		"",
+		`{"id": {{ID}}}`,
+		"",
+		"### Valid",
+		"GRAPHQL http://localhost/graphql",
+		"",
+		"query User($id: ID!) { user(id: $id) { name } }",
+		"",
+		`{"id": {{KNOWN}}}`,
+	}, "\n"))
+	env := &internal.Environment{Variables: map[string]string{"KNOWN": "1"}}
+
+	diagnostics := Validate(c, env)
+
+	assert.Equal(t, []string{
+		CodeInvalidGraphQL,
+		CodeInvalidGraphQL,
+		CodeUnresolvedVariable,
+		CodeUnresolvedVariable,
+	}, codes(diagnostics))
+	assert.Equal(t, "missing GraphQL query", diagnostics[0].Message)
+	assert.Equal(t, 2, diagnostics[0].Line)
+	assert.Contains(t, diagnostics[1].Message, "invalid GraphQL variables")
+	assert.Equal(t, 5, diagnostics[1].Line)
+	assert.Contains(t, diagnostics[2].Message, "'{{FIELD}}' in GraphQL query")
+	assert.Contains(t, diagnostics[3].Message, "'{{ID}}' in GraphQL variables")
+}
+
+func TestValidate_ShouldCheckWebSocketRequests(t *testing.T) {
+	c := parse(t, strings.Join([]string{
+		"### Chat",
+		"WEBSOCKET ws://localhost/chat",
+		"",
+		"===",
+		`{"name": "{{NAME}}"}`,
+		"=== wait-for-server",
+		"",
+		"### Plain HTTP",
+		"WEBSOCKET http://localhost/chat",
+		"",
+		"### WebSocket URL for HTTP",
+		"GET wss://localhost/users",
+	}, "\n"))
+
+	diagnostics := Validate(c, nil)
+
+	assert.Equal(t, []string{CodeUnresolvedVariable, CodeInvalidUrl, CodeInvalidUrl}, codes(diagnostics))
+	assert.Contains(t, diagnostics[0].Message, "'{{NAME}}' in WebSocket step 1")
+	assert.Contains(t, diagnostics[1].Message, "scheme must be ws or wss")
+	assert.Contains(t, diagnostics[2].Message, "scheme must be http or https")
+}
+
+func TestValidate_ShouldCheckGRPCTargets(t *testing.T) {
+	c := parse(t, strings.Join([]string{
+		"@HOST = localhost:9090",
+		"",
+		"### Say Hello",
+		"GRPC grpcs://{{HOST}}/jetter.test.Greeter/SayHello",
+		"",
+		`{"name": "jetter"}`,
+		"",
+		"### Missing Method",
+		"GRPC {{HOST}}/jetter.test.Greeter",
+	}, "\n"))
+
+	diagnostics := Validate(c, nil)
+
+	assert.Equal(t, []string{CodeInvalidUrl}, codes(diagnostics))
+	assert.Contains(t, diagnostics[0].Message, "invalid gRPC target 'localhost:9090/jetter.test.Greeter'")
+}
+
+func TestValidate_ScopedVariablesAreOnlyDefinedFromTheirDeclaration(t *testing.T) {
+	c := parse(t, strings.Join([]string{
+		"### Before",
+		"GET http://localhost/users/{{ID}}",
+		"",
+		"### After",
+		"@ID = {{PREFIX}}1",
+		"@HOST = 127.0.0.1",
+		"GET http://{{HOST}}/users/{{ID}}",
+		"",
+		"### Invalid URL",
+		"@HOST = local host",
+		"GET http://{{HOST}}/users",
+	}, "\n"))
+
+	diagnostics := Validate(c, nil)
+
+	assert.Equal(t, []string{CodeUnresolvedVariable, CodeUnresolvedVariable, CodeInvalidUrl}, codes(diagnostics))
+	assert.Contains(t, diagnostics[0].Message, "'{{PREFIX}}' in variable 'ID'")
+	assert.Equal(t, 5, diagnostics[0].Line)
+	assert.Contains(t, diagnostics[1].Message, "'{{ID}}' in URL")
+	assert.Equal(t, 2, diagnostics[1].Line)
+	assert.Equal(t, 11, diagnostics[2].Line)
+}
+
+func TestValidate_ShouldResolveRelativeUrls(t *testing.T) {
+	content := strings.Join([]string{
+		"### Users",
+		"GET /users",
+		"",
+		"### Chat",
+		"WEBSOCKET /chat",
+	}, "\n")
+
+	t.Run("without base URL", func(t *testing.T) {
+		diagnostics := Validate(parse(t, content), nil)
+
+		assert.Equal(t, []string{CodeInvalidUrl, CodeInvalidUrl}, codes(diagnostics))
+		assert.Contains(t, diagnostics[0].Message, "relative URL '/users' without base URL")
+	})
+
+	t.Run("with base URL", func(t *testing.T) {
+		c := parse(t, content)
+		c.Config.BaseURL = "https://{{stage}}.example.com"
+		env := &internal.Environment{Variables: map[string]string{"stage": "staging"}}
+
+		assert.Empty(t, Validate(c, env))
+	})
+
+	t.Run("with baseUrl variable", func(t *testing.T) {
+		env := &internal.Environment{Variables: map[string]string{"baseUrl": "localhost:8080"}}
+
+		diagnostics := Validate(parse(t, content), env)
+
+		assert.Equal(t, []string{CodeInvalidUrl, CodeInvalidUrl}, codes(diagnostics))
+		assert.Contains(t, diagnostics[0].Message, "invalid URL 'localhost:8080/users'")
+	})
+}
+
+func TestValidate_ShouldReportVariableCycles(t *testing.T) {
+	c := parse(t, strings.Join([]string{
+		"@api = {{URL}}/v2",
+		"@a = {{b}}",
+		"@b = {{a}}",
+		"",
+		"### Users",
+		"GET {{api}}/users",
+	}, "\n"))
+	env := &internal.Environment{Variables: map[string]string{"URL": "http://{{HOST}}", "HOST": "localhost"}}
+
+	diagnostics := Validate(c, env)
+
+	assert.Equal(t, []string{CodeVariableCycle}, codes(diagnostics))
+	assert.Equal(t, "variable cycle: a -> b -> a", diagnostics[0].Message)
+	assert.Equal(t, 2, diagnostics[0].Line)
+}
+
+func TestValidate_ShouldCheckUrlsWithNestedVariables(t *testing.T) {
+	c := parse(t, strings.Join([]string{
+		"@api = {{URL}}/v2",
+		"@URL = localhost",
+		"",
+		"### Users",
+		"GET {{api}}/users",
+	}, "\n"))
+
+	diagnostics := Validate(c, nil)
+
+	assert.Equal(t, []string{CodeInvalidUrl}, codes(diagnostics))
+	assert.Contains(t, diagnostics[0].Message, "invalid URL 'localhost/v2/users'")
+}
+
+func TestValidate_ShouldAgreeWithExecutorOnSpacesAroundNames(t *testing.T) {
+	c := parse(t, strings.Join([]string{
+		"@tok = abc",
+		"",
+		"###",
+		"GET http://localhost/users",
+		"Authorization: Bearer {{ tok }}",
+		"X-Token: {{tok}}",
+	}, "\n"))
+
+	diagnostics := Validate(c, nil)
+	requests, err := executor.Evaluate(&c)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{CodeUnresolvedVariable}, codes(diagnostics))
+	assert.Equal(t, "'{{ tok }}' in header 'Authorization' is not substituted, as the variable name is surrounded by spaces", diagnostics[0].Message)
+	assert.Equal(t, "write it as '{{tok}}'", diagnostics[0].Fix)
+	assert.Equal(t, "Bearer {{ tok }}", requests[0].Headers["Authorization"])
+	assert.Equal(t, "abc", requests[0].Headers["X-Token"])
+}